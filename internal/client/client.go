@@ -3,10 +3,13 @@ package client
 import (
 	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
-	"io"
+	"crypto/tls"
+	"crypto/x509"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
 )
 
 type Client struct {
@@ -14,13 +17,39 @@ type Client struct {
 	token            string
 	adminName        string
 	adminKey         string
+	adminCert        string
 	adminProvisioner string
 	adminToken       string
 	httpClient       *http.Client
+
+	provisioner         string
+	provisionerKey      string
+	provisionerPassword string
+
+	maxAttempts int
+	retryBudget time.Duration
+	backoffBase time.Duration
+
+	rootFingerprint string
+	rootCerts       []*x509.Certificate
+	trustMu         sync.Mutex
+	trusted         bool
+
+	keyMu          sync.Mutex
+	adminJWT       *jwtSigner
+	provisionerJWT *jwtSigner
 }
 
+// New returns a client for the CA at baseURL. A trailing slash is ignored.
 func New(baseURL, token string) *Client {
-	return &Client{baseURL: baseURL, token: token, httpClient: &http.Client{}}
+	return &Client{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		token:       token,
+		httpClient:  &http.Client{},
+		maxAttempts: defaultMaxAttempts,
+		retryBudget: defaultRetryBudget,
+		backoffBase: defaultBackoffBase,
+	}
 }
 
 func (c *Client) WithAdminToken(t string) *Client {
@@ -35,6 +64,16 @@ func (c *Client) WithAdminName(name string) *Client {
 
 func (c *Client) WithAdminKey(key string) *Client {
 	c.adminKey = key
+	c.adminJWT = nil
+	return c
+}
+
+// WithAdminCertificate sets the certificate chain (path or PEM) of the admin
+// key. step-ca only accepts admin tokens carrying a certificate issued by the
+// admin provisioner.
+func (c *Client) WithAdminCertificate(cert string) *Client {
+	c.adminCert = cert
+	c.adminJWT = nil
 	return c
 }
 
@@ -43,53 +82,90 @@ func (c *Client) WithAdminProvisioner(p string) *Client {
 	return c
 }
 
-// Sign sends a CSR to the /sign endpoint and returns the certificate PEM bytes.
-func (c *Client) Sign(ctx context.Context, csr string) ([]byte, error) {
-	body := map[string]string{"csr": csr, "ott": c.token}
-	b, err := json.Marshal(body)
-	if err != nil {
-		return nil, err
+// WithProvisioner sets the JWK provisioner used to mint one-time sign tokens.
+func (c *Client) WithProvisioner(name string) *Client {
+	c.provisioner = name
+	c.provisionerJWT = nil
+	return c
+}
+
+// WithProvisionerKey sets the provisioner private key (path, PEM, JWK or encrypted JWK).
+func (c *Client) WithProvisionerKey(key string) *Client {
+	c.provisionerKey = key
+	c.provisionerJWT = nil
+	return c
+}
+
+// WithProvisionerPassword sets the password protecting the provisioner key.
+func (c *Client) WithProvisionerPassword(password string) *Client {
+	c.provisionerPassword = password
+	c.provisionerJWT = nil
+	return c
+}
+
+// SignResult is the response of the /sign and /renew endpoints.
+type SignResult struct {
+	// Certificate is the issued leaf certificate PEM.
+	Certificate string `json:"crt"`
+	// CA is the PEM of the certificate that issued the leaf.
+	CA string `json:"ca"`
+	// CertChain holds the leaf followed by its intermediates, one PEM each.
+	CertChain  []string    `json:"certChain"`
+	TLSOptions *TLSOptions `json:"tlsOptions,omitempty"`
+}
+
+// TLSOptions are the TLS settings step-ca recommends for issued certificates.
+type TLSOptions struct {
+	CipherSuites  []string `json:"cipherSuites,omitempty"`
+	MinVersion    float64  `json:"minVersion,omitempty"`
+	MaxVersion    float64  `json:"maxVersion,omitempty"`
+	Renegotiation bool     `json:"renegotiation,omitempty"`
+}
+
+// Chain returns the leaf followed by its intermediates. Older CAs that do
+// not send certChain get the leaf and the issuing CA.
+func (r *SignResult) Chain() []string {
+	if len(r.CertChain) > 0 {
+		return r.CertChain
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/sign", c.baseURL), bytes.NewReader(b))
-	if err != nil {
-		return nil, err
+	chain := []string{r.Certificate}
+	if r.CA != "" {
+		chain = append(chain, r.CA)
 	}
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := c.httpClient.Do(req)
+	return chain
+}
+
+// Sign sends a CSR to the /sign endpoint and returns the issued certificate
+// together with its chain.
+func (c *Client) Sign(ctx context.Context, csr string) (*SignResult, error) {
+	ott, err := c.signToken(ctx, csr)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
-	}
-	var result struct {
-		Cert string `json:"crt"`
+	var result SignResult
+	r := request{method: http.MethodPost, path: "/sign", body: map[string]string{"csr": csr, "ott": ott}}
+	if err := c.sendJSON(ctx, r, &result); err != nil {
+		return nil, err
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	return &result, nil
+}
+
+// Renew exchanges a still valid certificate for a new one through /renew,
+// authenticating with mTLS using the certificate and its key.
+func (c *Client) Renew(ctx context.Context, cert tls.Certificate) (*SignResult, error) {
+	var result SignResult
+	if err := c.sendJSON(ctx, request{method: http.MethodPost, path: "/renew", clientCert: &cert}, &result); err != nil {
 		return nil, err
 	}
-	return []byte(result.Cert), nil
+	return &result, nil
 }
 
 // Certificate retrieves a certificate by serial number via /certificates/{serial}.
 func (c *Client) Certificate(ctx context.Context, serial string) ([]byte, bool, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/certificates/%s", c.baseURL, serial), nil)
-	if err != nil {
-		return nil, false, err
-	}
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, false, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode == http.StatusNotFound {
+	b, err := c.send(ctx, request{method: http.MethodGet, path: "/certificates/" + url.PathEscape(serial)})
+	if IsNotFound(err) {
 		return nil, false, nil
 	}
-	if resp.StatusCode >= 300 {
-		return nil, false, fmt.Errorf("unexpected status: %s", resp.Status)
-	}
-	b, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, false, err
 	}
@@ -98,19 +174,7 @@ func (c *Client) Certificate(ctx context.Context, serial string) ([]byte, bool,
 
 // Version retrieves the version string from the /version endpoint.
 func (c *Client) Version(ctx context.Context) (string, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/version", c.baseURL), nil)
-	if err != nil {
-		return "", err
-	}
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		return "", fmt.Errorf("unexpected status: %s", resp.Status)
-	}
-	b, err := io.ReadAll(resp.Body)
+	b, err := c.send(ctx, request{method: http.MethodGet, path: "/version"})
 	if err != nil {
 		return "", err
 	}
@@ -119,21 +183,5 @@ func (c *Client) Version(ctx context.Context) (string, error) {
 
 // RootCertificate retrieves the root certificate PEM from the /root endpoint.
 func (c *Client) RootCertificate(ctx context.Context) ([]byte, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/root", c.baseURL), nil)
-	if err != nil {
-		return nil, err
-	}
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
-	}
-	b, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	return b, nil
+	return c.send(ctx, request{method: http.MethodGet, path: "/root"})
 }
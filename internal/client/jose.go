@@ -0,0 +1,335 @@
+package client
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/go-jose/go-jose/v3"
+)
+
+// jwtSigner signs compact JWS tokens with a private key loaded from PEM or JWK material.
+type jwtSigner struct {
+	key jose.JSONWebKey
+	alg jose.SignatureAlgorithm
+	// x5c is the certificate chain of the key, leaf first, as standard
+	// base64 DER. When set it is sent in the x5c header.
+	x5c []string
+}
+
+// newJWTSignerFromMaterial parses PEM, JWK or JWE-encrypted JWK key material.
+// The password is only used for encrypted JWKs as produced by step.
+func newJWTSignerFromMaterial(material []byte, password string) (*jwtSigner, error) {
+	trimmed := strings.TrimSpace(string(material))
+	if isCompactJWE(trimmed) {
+		if password == "" {
+			return nil, fmt.Errorf("key is encrypted but no password was provided")
+		}
+		plain, err := decryptJWE(trimmed, password)
+		if err != nil {
+			return nil, fmt.Errorf("decrypt key: %w", err)
+		}
+		trimmed = string(plain)
+	}
+	if strings.HasPrefix(trimmed, "{") {
+		return newJWTSignerFromJWK([]byte(trimmed))
+	}
+	key, err := parsePrivateKey([]byte(trimmed))
+	if err != nil {
+		return nil, err
+	}
+	return newJWTSigner(key)
+}
+
+// newJWTSignerFromJWK keeps the key ID step recorded in the JWK, if any, so
+// it matches the provisioner.
+func newJWTSignerFromJWK(data []byte) (*jwtSigner, error) {
+	var jwk jose.JSONWebKey
+	if err := jwk.UnmarshalJSON(data); err != nil {
+		return nil, fmt.Errorf("decode JWK: %w", err)
+	}
+	if jwk.IsPublic() {
+		return nil, fmt.Errorf("JWK does not contain a private key")
+	}
+	key, ok := jwk.Key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported JWK key type %T", jwk.Key)
+	}
+	signer, err := newJWTSigner(key)
+	if err != nil {
+		return nil, err
+	}
+	if jwk.KeyID != "" {
+		signer.key.KeyID = jwk.KeyID
+	}
+	return signer, nil
+}
+
+func newJWTSigner(key crypto.Signer) (*jwtSigner, error) {
+	alg, err := jwsAlgorithm(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	kid, err := jwkThumbprint(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	return &jwtSigner{key: jose.JSONWebKey{Key: key, KeyID: kid, Algorithm: string(alg)}, alg: alg}, nil
+}
+
+// sign serializes claims and returns the compact JWS representation.
+func (s *jwtSigner) sign(claims any) (string, error) {
+	opts := (&jose.SignerOptions{}).WithType("JWT")
+	if len(s.x5c) > 0 {
+		opts = opts.WithHeader("x5c", s.x5c)
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: s.alg, Key: s.key}, opts)
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	jws, err := signer.Sign(payload)
+	if err != nil {
+		return "", err
+	}
+	return jws.CompactSerialize()
+}
+
+// withCertificates returns a copy of the signer that sends chain in the x5c
+// header. The leaf must certify the signing key.
+func (s *jwtSigner) withCertificates(chain []*x509.Certificate) (*jwtSigner, error) {
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no certificates found")
+	}
+	leaf, ok := chain[0].PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !leaf.Equal(s.key.Key.(crypto.Signer).Public()) {
+		return nil, fmt.Errorf("the first certificate does not match the private key")
+	}
+	out := *s
+	out.x5c = make([]string, len(chain))
+	for i, cert := range chain {
+		out.x5c[i] = base64.StdEncoding.EncodeToString(cert.Raw)
+	}
+	return &out, nil
+}
+
+func jwsAlgorithm(pub crypto.PublicKey) (jose.SignatureAlgorithm, error) {
+	switch k := pub.(type) {
+	case ed25519.PublicKey:
+		return jose.EdDSA, nil
+	case *rsa.PublicKey:
+		return jose.RS256, nil
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return jose.ES256, nil
+		case elliptic.P384():
+			return jose.ES384, nil
+		case elliptic.P521():
+			return jose.ES512, nil
+		}
+		return "", fmt.Errorf("unsupported elliptic curve %s", k.Curve.Params().Name)
+	default:
+		return "", fmt.Errorf("unsupported key type %T", pub)
+	}
+}
+
+// jwkThumbprint computes the RFC 7638 SHA-256 thumbprint step uses as key ID.
+func jwkThumbprint(pub crypto.PublicKey) (string, error) {
+	jwk := jose.JSONWebKey{Key: pub}
+	sum, err := jwk.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(sum), nil
+}
+
+var keyURIPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:[^\\]`)
+
+// loadKeyMaterial returns inline PEM or JWK content as-is and reads anything else from disk.
+func loadKeyMaterial(value string) ([]byte, error) {
+	trimmed := strings.TrimSpace(value)
+	if strings.HasPrefix(trimmed, "-----BEGIN") || strings.HasPrefix(trimmed, "{") || isCompactJWE(trimmed) {
+		return []byte(trimmed), nil
+	}
+	if keyURIPattern.MatchString(trimmed) {
+		return nil, fmt.Errorf("key URI %q is not supported; provide a PEM or JWK key file or use a pre-generated token", trimmed)
+	}
+	b, err := os.ReadFile(trimmed)
+	if err != nil {
+		return nil, fmt.Errorf("read key: %w", err)
+	}
+	return b, nil
+}
+
+// loadCertificates parses an inline PEM bundle or reads one from disk.
+func loadCertificates(value string) ([]*x509.Certificate, error) {
+	data := []byte(strings.TrimSpace(value))
+	if !strings.HasPrefix(string(data), "-----BEGIN") {
+		b, err := os.ReadFile(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("read certificate: %w", err)
+		}
+		data = b
+	}
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("parse certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found")
+	}
+	return certs, nil
+}
+
+// parsePrivateKey decodes an unencrypted PEM private key.
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	rest := []byte(strings.TrimSpace(string(data)))
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, fmt.Errorf("no private key found in key material")
+		}
+		if block.Type == "ENCRYPTED PRIVATE KEY" || block.Headers["Proc-Type"] != "" {
+			return nil, fmt.Errorf("encrypted PEM keys are not supported; decrypt the key first")
+		}
+		var (
+			key any
+			err error
+		)
+		switch block.Type {
+		case "PRIVATE KEY":
+			key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		case "EC PRIVATE KEY":
+			key, err = x509.ParseECPrivateKey(block.Bytes)
+		case "RSA PRIVATE KEY":
+			key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	}
+}
+
+// isCompactJWE reports whether s looks like a five-part compact JWE.
+func isCompactJWE(s string) bool {
+	return strings.HasPrefix(s, "eyJ") && strings.Count(s, ".") == 4 && !strings.ContainsAny(s, " \n/")
+}
+
+// decryptJWE decrypts a password-protected compact JWE (PBES2 key wrapping
+// with AES-GCM content encryption), the format step uses for encrypted keys.
+func decryptJWE(token, password string) ([]byte, error) {
+	jwe, err := jose.ParseEncrypted(token)
+	if err != nil {
+		return nil, fmt.Errorf("malformed JWE: %w", err)
+	}
+	switch alg := jose.KeyAlgorithm(jwe.Header.Algorithm); alg {
+	case jose.PBES2_HS256_A128KW, jose.PBES2_HS384_A192KW, jose.PBES2_HS512_A256KW:
+	default:
+		return nil, fmt.Errorf("unsupported JWE key algorithm %q", alg)
+	}
+	plain, err := jwe.Decrypt([]byte(password))
+	if err != nil {
+		return nil, fmt.Errorf("invalid password or corrupted key")
+	}
+	return plain, nil
+}
+
+// pbes2Iterations matches the PBKDF2 iteration count step uses for new keys.
+const pbes2Iterations = 600000
+
+// encryptJWE encrypts plaintext into a password-protected compact JWE using
+// PBES2-HS256+A128KW key wrapping and A256GCM, the format step writes and
+// decryptJWE reads.
+func encryptJWE(plaintext []byte, password, contentType string) (string, error) {
+	recipient := jose.Recipient{
+		Algorithm:  jose.PBES2_HS256_A128KW,
+		Key:        []byte(password),
+		PBES2Count: pbes2Iterations,
+	}
+	opts := (&jose.EncrypterOptions{}).WithContentType(jose.ContentType(contentType))
+	encrypter, err := jose.NewEncrypter(jose.A256GCM, recipient, opts)
+	if err != nil {
+		return "", err
+	}
+	jwe, err := encrypter.Encrypt(plaintext)
+	if err != nil {
+		return "", err
+	}
+	return jwe.CompactSerialize()
+}
+
+// JWKProvisionerKey is a key pair for a JWK provisioner in the form step-ca
+// stores it.
+type JWKProvisionerKey struct {
+	// PublicKey is the public JWK as JSON.
+	PublicKey string
+	// EncryptedKey is the private JWK encrypted with the provisioner
+	// password as a compact JWE.
+	EncryptedKey string
+}
+
+// GenerateJWKProvisionerKey creates a P-256 key pair like
+// `step ca provisioner add --create` and encrypts the private JWK with
+// password.
+func GenerateJWKProvisionerKey(password string) (*JWKProvisionerKey, error) {
+	if password == "" {
+		return nil, fmt.Errorf("a password is required to encrypt the provisioner key")
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	kid, err := jwkThumbprint(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	jwk := jose.JSONWebKey{Key: key, KeyID: kid, Algorithm: string(jose.ES256), Use: "sig"}
+	public, err := jwk.Public().MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	private, err := jwk.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := encryptJWE(private, password, "jwk+json")
+	if err != nil {
+		return nil, err
+	}
+	return &JWKProvisionerKey{PublicKey: string(public), EncryptedKey: encrypted}, nil
+}
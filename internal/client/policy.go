@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Policy restricts the names that may appear in certificates. It is used
+// both for a single provisioner and, through the admin API, for the whole
+// authority. Names matching a deny rule are rejected even if they also match
+// an allow rule.
+type Policy struct {
+	X509 *X509Policy `json:"x509,omitempty"`
+	SSH  *SSHPolicy  `json:"ssh,omitempty"`
+}
+
+// X509Policy holds the allowed and denied names of X.509 certificates.
+type X509Policy struct {
+	Allow              *X509Names `json:"allow,omitempty"`
+	Deny               *X509Names `json:"deny,omitempty"`
+	AllowWildcardNames bool       `json:"allowWildcardNames,omitempty"`
+}
+
+// X509Names lists name patterns of X.509 certificates. DNS names may start
+// with "*." to match subdomains and IPs may be CIDR ranges.
+type X509Names struct {
+	CommonNames []string `json:"commonNames,omitempty"`
+	DNS         []string `json:"dns,omitempty"`
+	IPs         []string `json:"ips,omitempty"`
+	Emails      []string `json:"emails,omitempty"`
+	URIs        []string `json:"uris,omitempty"`
+}
+
+// SSHPolicy holds the policies of SSH user and host certificates.
+type SSHPolicy struct {
+	User *SSHUserPolicy `json:"user,omitempty"`
+	Host *SSHHostPolicy `json:"host,omitempty"`
+}
+
+// SSHUserPolicy holds the allowed and denied names of SSH user certificates.
+type SSHUserPolicy struct {
+	Allow *SSHUserNames `json:"allow,omitempty"`
+	Deny  *SSHUserNames `json:"deny,omitempty"`
+}
+
+// SSHUserNames lists name patterns of SSH user certificates.
+type SSHUserNames struct {
+	Emails     []string `json:"emails,omitempty"`
+	Principals []string `json:"principals,omitempty"`
+}
+
+// SSHHostPolicy holds the allowed and denied names of SSH host certificates.
+type SSHHostPolicy struct {
+	Allow *SSHHostNames `json:"allow,omitempty"`
+	Deny  *SSHHostNames `json:"deny,omitempty"`
+}
+
+// SSHHostNames lists name patterns of SSH host certificates.
+type SSHHostNames struct {
+	DNS        []string `json:"dns,omitempty"`
+	IPs        []string `json:"ips,omitempty"`
+	Principals []string `json:"principals,omitempty"`
+}
+
+const authorityPolicyPath = "/admin/policy"
+
+// GetAuthorityPolicy fetches the policy that applies to every provisioner of
+// the authority. It returns nil when no authority policy is set.
+func (c *Client) GetAuthorityPolicy(ctx context.Context) (*Policy, error) {
+	var p Policy
+	err := c.sendJSON(ctx, request{method: http.MethodGet, path: authorityPolicyPath, admin: true}, &p)
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// CreateAuthorityPolicy sets the authority policy. step-ca rejects it when a
+// policy already exists.
+func (c *Client) CreateAuthorityPolicy(ctx context.Context, p Policy) error {
+	_, err := c.send(ctx, request{method: http.MethodPost, path: authorityPolicyPath, body: p, admin: true})
+	return err
+}
+
+// UpdateAuthorityPolicy replaces the existing authority policy.
+func (c *Client) UpdateAuthorityPolicy(ctx context.Context, p Policy) error {
+	_, err := c.send(ctx, request{method: http.MethodPut, path: authorityPolicyPath, body: p, admin: true})
+	return err
+}
+
+// DeleteAuthorityPolicy removes the authority policy. A missing policy is
+// ignored.
+func (c *Client) DeleteAuthorityPolicy(ctx context.Context) error {
+	_, err := c.send(ctx, request{method: http.MethodDelete, path: authorityPolicyPath, admin: true})
+	if IsNotFound(err) {
+		return nil
+	}
+	return err
+}
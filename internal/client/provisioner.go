@@ -1,40 +1,209 @@
 package client
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
 	"net/http"
+	"net/url"
 )
 
 // Provisioner represents a simple provisioner configuration.
 type Provisioner struct {
+	// ID is assigned by step-ca and stays the same across updates.
+	ID                  string `json:"id,omitempty"`
 	Name                string `json:"name"`
 	Type                string `json:"type"`
 	Admin               bool   `json:"admin,omitempty"`
 	X509Template        string `json:"x509Template,omitempty"`
 	SSHTemplate         string `json:"sshTemplate,omitempty"`
 	AttestationTemplate string `json:"attestationTemplate,omitempty"`
+	// X509TemplateData and SSHTemplateData hold a JSON object whose keys
+	// step-ca adds to the data the templates are rendered with.
+	X509TemplateData json.RawMessage `json:"x509TemplateData,omitempty"`
+	SSHTemplateData  json.RawMessage `json:"sshTemplateData,omitempty"`
+
+	Details *ProvisionerDetails `json:"details,omitempty"`
+	Claims  *ProvisionerClaims  `json:"claims,omitempty"`
+	Policy  *Policy             `json:"policy,omitempty"`
+}
+
+// ProvisionerClaims holds the certificate lifetimes and flags of a
+// provisioner. Durations use Go's duration syntax; step-ca reports them
+// normalized, such as "24h0m0s".
+type ProvisionerClaims struct {
+	X509                       *X509Claims `json:"x509,omitempty"`
+	SSH                        *SSHClaims  `json:"ssh,omitempty"`
+	DisableRenewal             bool        `json:"disableRenewal,omitempty"`
+	AllowRenewalAfterExpiry    bool        `json:"allowRenewalAfterExpiry,omitempty"`
+	DisableSmallstepExtensions bool        `json:"disableSmallstepExtensions,omitempty"`
+}
+
+// X509Claims holds the lifetimes of X.509 certificates.
+type X509Claims struct {
+	Enabled   bool                  `json:"enabled,omitempty"`
+	Durations *ProvisionerDurations `json:"durations,omitempty"`
+}
+
+// SSHClaims enables the SSH CA for a provisioner and holds the lifetimes of
+// its user and host certificates.
+type SSHClaims struct {
+	Enabled       bool                  `json:"enabled,omitempty"`
+	UserDurations *ProvisionerDurations `json:"userDurations,omitempty"`
+	HostDurations *ProvisionerDurations `json:"hostDurations,omitempty"`
+}
+
+// ProvisionerDurations bounds the lifetime of certificates.
+type ProvisionerDurations struct {
+	Default string `json:"default,omitempty"`
+	Min     string `json:"min,omitempty"`
+	Max     string `json:"max,omitempty"`
+}
+
+// ProvisionerDetails holds the type specific configuration of a provisioner.
+// At most one field is set, named after the provisioner type as in step-ca's
+// admin API.
+type ProvisionerDetails struct {
+	JWK    *JWKProvisioner    `json:"JWK,omitempty"`
+	OIDC   *OIDCProvisioner   `json:"OIDC,omitempty"`
+	ACME   *ACMEProvisioner   `json:"ACME,omitempty"`
+	AWS    *AWSProvisioner    `json:"AWS,omitempty"`
+	GCP    *GCPProvisioner    `json:"GCP,omitempty"`
+	Azure  *AzureProvisioner  `json:"Azure,omitempty"`
+	X5C    *X5CProvisioner    `json:"X5C,omitempty"`
+	K8sSA  *K8sSAProvisioner  `json:"K8sSA,omitempty"`
+	SSHPOP *SSHPOPProvisioner `json:"SSHPOP,omitempty"`
+	SCEP   *SCEPProvisioner   `json:"SCEP,omitempty"`
+	Nebula *NebulaProvisioner `json:"Nebula,omitempty"`
+}
+
+// JWKProvisioner is the configuration of a JWK provisioner. The admin API
+// transports both keys base64 encoded.
+type JWKProvisioner struct {
+	// PublicKey is the public JWK as JSON.
+	PublicKey []byte `json:"publicKey"`
+	// EncryptedPrivateKey is the password-encrypted private JWK as a
+	// compact JWE. Without it tokens must be signed with a key held
+	// elsewhere.
+	EncryptedPrivateKey []byte `json:"encryptedPrivateKey,omitempty"`
+}
+
+// OIDCProvisioner is the configuration of an OIDC provisioner.
+type OIDCProvisioner struct {
+	ClientID     string `json:"clientId"`
+	ClientSecret string `json:"clientSecret,omitempty"`
+	// ConfigurationEndpoint is the identity provider's OpenID Connect
+	// discovery document, ending in /.well-known/openid-configuration.
+	ConfigurationEndpoint string `json:"configurationEndpoint"`
+	// Admins are the emails of users that may sign certificates for any
+	// principal.
+	Admins []string `json:"admins,omitempty"`
+	// Domains restricts the email domains of users that may get
+	// certificates.
+	Domains []string `json:"domains,omitempty"`
+	// Groups restricts the groups of users that may get certificates.
+	Groups        []string `json:"groups,omitempty"`
+	ListenAddress string   `json:"listenAddress,omitempty"`
+	TenantID      string   `json:"tenantId,omitempty"`
+}
+
+// ACMEProvisioner is the configuration of an ACME provisioner. Challenges
+// and attestation formats use the admin API's enum names, such as HTTP_01 and
+// APPLE.
+type ACMEProvisioner struct {
+	ForceCN    bool     `json:"forceCn,omitempty"`
+	RequireEAB bool     `json:"requireEab,omitempty"`
+	Challenges []string `json:"challenges,omitempty"`
+	// AttestationFormats lists the formats accepted by the
+	// device-attest-01 challenge.
+	AttestationFormats []string `json:"attestationFormats,omitempty"`
+	// AttestationRoots is a PEM bundle of the roots that attestation
+	// certificates must chain to. The admin API transports it base64
+	// encoded.
+	AttestationRoots []byte `json:"attestationRoots,omitempty"`
+}
+
+// AWSProvisioner is the configuration of an AWS provisioner, which accepts
+// EC2 instance identity documents.
+type AWSProvisioner struct {
+	Accounts               []string `json:"accounts,omitempty"`
+	DisableCustomSANs      bool     `json:"disableCustomSans,omitempty"`
+	DisableTrustOnFirstUse bool     `json:"disableTrustOnFirstUse,omitempty"`
+	// InstanceAge is the maximum age of an instance as a duration, such as
+	// "1h".
+	InstanceAge string `json:"instanceAge,omitempty"`
+	// IIDRoots holds the PEM certificates that instance identity documents
+	// are verified with, one per entry.
+	IIDRoots []string `json:"iidRoots,omitempty"`
+}
+
+// GCPProvisioner is the configuration of a GCP provisioner, which accepts
+// instance identity tokens.
+type GCPProvisioner struct {
+	ServiceAccounts []string `json:"serviceAccounts,omitempty"`
+	ProjectIDs      []string `json:"projectIds,omitempty"`
+}
+
+// AzureProvisioner is the configuration of an Azure provisioner, which
+// accepts managed identity tokens.
+type AzureProvisioner struct {
+	TenantID        string   `json:"tenantId"`
+	ResourceGroups  []string `json:"resourceGroups,omitempty"`
+	SubscriptionIDs []string `json:"subscriptionIds,omitempty"`
+	ObjectIDs       []string `json:"objectIds,omitempty"`
+}
+
+// X5CProvisioner is the configuration of an X5C provisioner, which accepts
+// tokens signed by a certificate chaining to one of Roots.
+type X5CProvisioner struct {
+	// Roots holds one PEM certificate per entry.
+	Roots [][]byte `json:"roots"`
+}
+
+// K8sSAProvisioner is the configuration of a K8sSA provisioner, which
+// accepts Kubernetes service account tokens.
+type K8sSAProvisioner struct {
+	// PublicKeys holds the PEM public keys that verify service account
+	// tokens, one entry per configured key.
+	PublicKeys [][]byte `json:"publicKeys,omitempty"`
+}
+
+// SSHPOPProvisioner is the configuration of an SSHPOP provisioner, which
+// renews and revokes SSH certificates. It has no settings.
+type SSHPOPProvisioner struct{}
+
+// SCEPProvisioner is the configuration of a SCEP provisioner.
+type SCEPProvisioner struct {
+	// Challenge is the shared secret clients present when they enroll.
+	Challenge              string   `json:"challenge,omitempty"`
+	Capabilities           []string `json:"capabilities,omitempty"`
+	MinimumPublicKeyLength int      `json:"minimumPublicKeyLength,omitempty"`
+	IncludeRoot            bool     `json:"includeRoot,omitempty"`
+	// EncryptionAlgorithmIdentifier selects the content encryption of
+	// responses: 0 DES-CBC, 1 AES-128-CBC, 2 AES-256-CBC, 3 AES-128-GCM or
+	// 4 AES-256-GCM.
+	EncryptionAlgorithmIdentifier int            `json:"encryptionAlgorithmIdentifier,omitempty"`
+	Decrypter                     *SCEPDecrypter `json:"decrypter,omitempty"`
+}
+
+// SCEPDecrypter is the certificate and key that decrypt SCEP requests. The
+// admin API transports the PEM certificate and key base64 encoded.
+type SCEPDecrypter struct {
+	Certificate []byte `json:"certificate,omitempty"`
+	Key         []byte `json:"key,omitempty"`
+	KeyPassword string `json:"keyPassword,omitempty"`
+}
+
+// NebulaProvisioner is the configuration of a Nebula provisioner, which
+// accepts tokens signed by hosts with a Nebula certificate.
+type NebulaProvisioner struct {
+	// Roots holds one PEM Nebula CA certificate per entry.
+	Roots [][]byte `json:"roots"`
 }
 
 // ListProvisioners retrieves all provisioners available via the admin API.
 func (c *Client) ListProvisioners(ctx context.Context) ([]Provisioner, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/admin/provisioners", c.baseURL), nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", "Bearer "+c.adminToken)
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
-	}
 	var out []Provisioner
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+	if err := c.sendJSON(ctx, request{method: http.MethodGet, path: "/admin/provisioners", admin: true}, &out); err != nil {
 		return nil, err
 	}
 	return out, nil
@@ -42,92 +211,38 @@ func (c *Client) ListProvisioners(ctx context.Context) ([]Provisioner, error) {
 
 // CreateProvisioner adds a new provisioner using the admin API.
 func (c *Client) CreateProvisioner(ctx context.Context, p Provisioner) error {
-	b, err := json.Marshal(p)
-	if err != nil {
-		return err
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/admin/provisioners", c.baseURL), bytes.NewReader(b))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.adminToken)
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		return fmt.Errorf("unexpected status: %s", resp.Status)
-	}
-	return nil
+	_, err := c.send(ctx, request{method: http.MethodPost, path: "/admin/provisioners", body: p, admin: true})
+	return err
 }
 
 // ReplaceProvisioner updates an existing provisioner.
 func (c *Client) ReplaceProvisioner(ctx context.Context, name string, p Provisioner) error {
-	b, err := json.Marshal(p)
-	if err != nil {
-		return err
-	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPut, fmt.Sprintf("%s/admin/provisioners/%s", c.baseURL, name), bytes.NewReader(b))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.adminToken)
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		return fmt.Errorf("unexpected status: %s", resp.Status)
-	}
-	return nil
+	_, err := c.send(ctx, request{method: http.MethodPut, path: provisionerPath(name), body: p, admin: true})
+	return err
 }
 
 // DeleteProvisioner removes a provisioner via the admin API.
 func (c *Client) DeleteProvisioner(ctx context.Context, name string) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("%s/admin/provisioners/%s", c.baseURL, name), nil)
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Authorization", "Bearer "+c.adminToken)
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode == http.StatusNotFound {
+	_, err := c.send(ctx, request{method: http.MethodDelete, path: provisionerPath(name), admin: true})
+	if IsNotFound(err) {
 		return nil
 	}
-	if resp.StatusCode >= 300 {
-		return fmt.Errorf("unexpected status: %s", resp.Status)
-	}
-	return nil
+	return err
 }
 
 // GetProvisioner retrieves a provisioner by name.
 func (c *Client) GetProvisioner(ctx context.Context, name string) (*Provisioner, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/admin/provisioners/%s", c.baseURL, name), nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", "Bearer "+c.adminToken)
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode == http.StatusNotFound {
+	var out Provisioner
+	err := c.sendJSON(ctx, request{method: http.MethodGet, path: provisionerPath(name), admin: true}, &out)
+	if IsNotFound(err) {
 		return nil, nil
 	}
-	if resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
-	}
-	var out Provisioner
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+	if err != nil {
 		return nil, err
 	}
 	return &out, nil
 }
+
+func provisionerPath(name string) string {
+	return "/admin/provisioners/" + url.PathEscape(name)
+}
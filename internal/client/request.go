@@ -0,0 +1,245 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"github.com/z0link/terraform-provider-stepca/internal/version"
+)
+
+const redacted = "[REDACTED]"
+
+// request describes a single call to the CA. Every client method goes through
+// send so authentication, retries, logging and error handling live in one place.
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   any
+	admin  bool
+	// clientCert authenticates the request with mTLS instead of a token.
+	clientCert *tls.Certificate
+	// bootstrap skips TLS verification for the root download, which checks
+	// the response against the pinned fingerprint itself.
+	bootstrap bool
+}
+
+// send executes r and returns the body of a successful response. Responses
+// with a status of 300 or above are returned as *APIError.
+func (c *Client) send(ctx context.Context, r request) ([]byte, error) {
+	if !r.bootstrap {
+		if err := c.ensureTrust(ctx); err != nil {
+			return nil, err
+		}
+	}
+	var payload []byte
+	if r.body != nil {
+		b, err := json.Marshal(r.body)
+		if err != nil {
+			return nil, err
+		}
+		payload = b
+	}
+	target := c.baseURL + r.path
+	if len(r.query) > 0 {
+		target += "?" + r.query.Encode()
+	}
+
+	httpClient := c.httpClient
+	switch {
+	case r.clientCert != nil:
+		httpClient = c.mtlsClient(*r.clientCert)
+	case r.bootstrap:
+		httpClient = c.bootstrapClient()
+	}
+
+	deadline := time.Now().Add(c.retryBudget)
+	for attempt := 1; ; attempt++ {
+		req, err := c.newRequest(ctx, r, target, payload)
+		if err != nil {
+			return nil, err
+		}
+		start := time.Now()
+		resp, err := httpClient.Do(req)
+		logAttempt(ctx, req, payload, resp, err, attempt, time.Since(start))
+
+		wait, retry := c.retryDelay(req, resp, err, attempt)
+		if retry && ctx.Err() == nil && !time.Now().Add(wait).After(deadline) {
+			if resp != nil {
+				_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBody))
+				resp.Body.Close()
+			}
+			if err := sleep(ctx, wait); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			apiErr := newAPIError(resp)
+			tflog.Debug(ctx, "step-ca error response", map[string]any{
+				"status":     apiErr.StatusCode,
+				"message":    redactString(apiErr.Message),
+				"request_id": apiErr.RequestID,
+			})
+			return nil, apiErr
+		}
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		tflog.Trace(ctx, "step-ca response body", map[string]any{"body": redactBody(body)})
+		return body, nil
+	}
+}
+
+// sendJSON executes r and decodes a successful JSON response into out.
+func (c *Client) sendJSON(ctx context.Context, r request, out any) error {
+	body, err := c.send(ctx, r)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, out)
+}
+
+func (c *Client) newRequest(ctx context.Context, r request, target string, payload []byte) (*http.Request, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent())
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if r.admin {
+		if err := c.authorizeAdmin(req); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+func userAgent() string {
+	return "terraform-provider-stepca/" + version.Version
+}
+
+func logAttempt(ctx context.Context, req *http.Request, payload []byte, resp *http.Response, err error, attempt int, latency time.Duration) {
+	fields := map[string]any{
+		"method":     req.Method,
+		"url":        req.URL.String(),
+		"attempt":    attempt,
+		"latency_ms": latency.Milliseconds(),
+		"headers":    redactHeaders(req.Header),
+	}
+	if resp != nil {
+		fields["status"] = resp.StatusCode
+	}
+	if err != nil {
+		fields["error"] = redactString(err.Error())
+	}
+	tflog.Debug(ctx, "step-ca request", fields)
+	if payload != nil {
+		tflog.Trace(ctx, "step-ca request body", map[string]any{"body": redactBody(payload)})
+	}
+}
+
+// redactHeaders flattens headers for logging and hides credentials.
+func redactHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for name, values := range h {
+		switch strings.ToLower(name) {
+		case "authorization", "cookie", "set-cookie":
+			out[name] = redacted
+		default:
+			out[name] = strings.Join(values, ", ")
+		}
+	}
+	return out
+}
+
+// secretFields lists JSON members (compared case-insensitively) that carry
+// tokens, passwords or key material and must never be logged.
+var secretFields = map[string]bool{
+	"ott":                  true,
+	"token":                true,
+	"password":             true,
+	"privatekey":           true,
+	"encryptedkey":         true,
+	"encryptedprivatekey":  true,
+	"clientsecret":         true,
+	"challenge":            true,
+	"decrypterkeypem":      true,
+	"decrypterkeypassword": true,
+	"d":                    true,
+	"p":                    true,
+	"q":                    true,
+	"dp":                   true,
+	"dq":                   true,
+	"qi":                   true,
+}
+
+// redactBody returns a JSON body with secret members replaced. Bodies that
+// are not JSON are scrubbed of anything that looks like a token or key.
+func redactBody(body []byte) string {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return redactString(string(body))
+	}
+	b, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return redacted
+	}
+	return string(b)
+}
+
+func redactValue(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			if secretFields[strings.ToLower(k)] {
+				t[k] = redacted
+				continue
+			}
+			t[k] = redactValue(val)
+		}
+		return t
+	case []any:
+		for i := range t {
+			t[i] = redactValue(t[i])
+		}
+		return t
+	case string:
+		return redactString(t)
+	default:
+		return v
+	}
+}
+
+var (
+	jwtPattern        = regexp.MustCompile(`eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*\.[A-Za-z0-9_.-]*`)
+	privateKeyPattern = regexp.MustCompile(`(?s)-----BEGIN [A-Z ]*PRIVATE KEY-----.*?-----END [A-Z ]*PRIVATE KEY-----`)
+)
+
+// redactString masks JWS/JWE tokens and PEM private keys inside free text.
+func redactString(s string) string {
+	s = privateKeyPattern.ReplaceAllString(s, redacted)
+	return jwtPattern.ReplaceAllString(s, redacted)
+}
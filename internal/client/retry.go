@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultMaxAttempts = 3
+	defaultRetryBudget = time.Minute
+	defaultBackoffBase = 500 * time.Millisecond
+	maxBackoff         = 15 * time.Second
+)
+
+// WithRetry configures how often and for how long requests are retried.
+// maxAttempts counts the first attempt; values below one disable retries.
+func (c *Client) WithRetry(maxAttempts int, budget time.Duration) *Client {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	c.maxAttempts = maxAttempts
+	c.retryBudget = budget
+	return c
+}
+
+// retryDelay decides whether an attempt should be retried and how long to wait.
+// Idempotent requests are retried on transport errors and 429/502/503/504
+// responses; other requests are only retried when the connection could not be
+// established, so nothing reached the CA.
+func (c *Client) retryDelay(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if attempt >= c.maxAttempts {
+		return 0, false
+	}
+	switch {
+	case err != nil:
+		var verifyErr *tls.CertificateVerificationError
+		if errors.As(err, &verifyErr) {
+			return 0, false
+		}
+		if !isIdempotent(req.Method) && !isDialError(err) {
+			return 0, false
+		}
+	case isIdempotent(req.Method) && isRetryableStatus(resp.StatusCode):
+		if wait, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return wait, true
+		}
+	default:
+		return 0, false
+	}
+	return c.backoff(attempt), true
+}
+
+// backoff returns an exponentially growing delay with equal jitter.
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.backoffBase << (attempt - 1)
+	if d <= 0 || d > maxBackoff {
+		d = maxBackoff
+	}
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isDialError reports whether err happened while connecting, before any
+// request bytes were written.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
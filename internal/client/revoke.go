@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// RevokeRequest describes a certificate to revoke through /revoke.
+type RevokeRequest struct {
+	// Serial is the certificate serial number in decimal, as step-ca expects it.
+	Serial     string
+	ReasonCode int
+	Reason     string
+	// ClientCertificate authenticates the request with mTLS using the
+	// certificate being revoked. Without it a one-time token is minted with the
+	// configured provisioner.
+	ClientCertificate *tls.Certificate
+}
+
+// Revoke passively revokes a certificate: step-ca records it as revoked and
+// refuses to renew it. Certificates that are already revoked are not an error.
+func (c *Client) Revoke(ctx context.Context, rr RevokeRequest) error {
+	body := map[string]any{
+		"serial":     rr.Serial,
+		"reasonCode": rr.ReasonCode,
+		"reason":     rr.Reason,
+		"passive":    true,
+	}
+	r := request{method: http.MethodPost, path: "/revoke", body: body, clientCert: rr.ClientCertificate}
+	if rr.ClientCertificate == nil {
+		if c.provisioner == "" {
+			return fmt.Errorf("revoking certificate %s requires a provisioner with its key or password, or the certificate's private key", rr.Serial)
+		}
+		ott, err := c.mintProvisionerToken(ctx, rr.Serial, c.baseURL+"/1.0/revoke", nil)
+		if err != nil {
+			return fmt.Errorf("mint revoke token: %w", err)
+		}
+		body["ott"] = ott
+	}
+	_, err := c.send(ctx, r)
+	if isAlreadyRevoked(err) {
+		return nil
+	}
+	return err
+}
+
+func isAlreadyRevoked(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest &&
+		strings.Contains(apiErr.Message, "already revoked")
+}
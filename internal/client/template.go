@@ -1,11 +1,9 @@
 package client
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"fmt"
 	"net/http"
+	"net/url"
 )
 
 // Template represents an X.509 or SSH template stored via the admin API.
@@ -17,79 +15,41 @@ type Template struct {
 
 // CreateTemplate stores a template that can later be attached to a provisioner.
 func (c *Client) CreateTemplate(ctx context.Context, tmpl Template) error {
-	return c.templateMutation(ctx, http.MethodPost, fmt.Sprintf("%s/admin/templates", c.baseURL), tmpl)
+	return c.templateMutation(ctx, http.MethodPost, "/admin/templates", tmpl)
 }
 
 // UpdateTemplate updates the rendered template payload in-place.
 func (c *Client) UpdateTemplate(ctx context.Context, tmpl Template) error {
-	path := fmt.Sprintf("%s/admin/templates/%s", c.baseURL, tmpl.Name)
-	return c.templateMutation(ctx, http.MethodPut, path, tmpl)
+	return c.templateMutation(ctx, http.MethodPut, templatePath(tmpl.Name), tmpl)
 }
 
 // DeleteTemplate removes a stored template by name. Missing templates are ignored.
 func (c *Client) DeleteTemplate(ctx context.Context, name string) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("%s/admin/templates/%s", c.baseURL, name), nil)
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Authorization", "Bearer "+c.adminToken)
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode == http.StatusNotFound {
+	_, err := c.send(ctx, request{method: http.MethodDelete, path: templatePath(name), admin: true})
+	if IsNotFound(err) {
 		return nil
 	}
-	if resp.StatusCode >= 300 {
-		return fmt.Errorf("unexpected status: %s", resp.Status)
-	}
-	return nil
+	return err
 }
 
 // GetTemplate fetches a template definition by name.
 func (c *Client) GetTemplate(ctx context.Context, name string) (*Template, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/admin/templates/%s", c.baseURL, name), nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", "Bearer "+c.adminToken)
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode == http.StatusNotFound {
+	var tmpl Template
+	err := c.sendJSON(ctx, request{method: http.MethodGet, path: templatePath(name), admin: true}, &tmpl)
+	if IsNotFound(err) {
 		return nil, nil
 	}
-	if resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("unexpected status: %s", resp.Status)
-	}
-	var tmpl Template
-	if err := json.NewDecoder(resp.Body).Decode(&tmpl); err != nil {
+	if err != nil {
 		return nil, err
 	}
 	return &tmpl, nil
 }
 
-func (c *Client) templateMutation(ctx context.Context, method, url string, tmpl Template) error {
-	b, err := json.Marshal(tmpl)
-	if err != nil {
-		return err
-	}
-	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(b))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Authorization", "Bearer "+c.adminToken)
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		return fmt.Errorf("unexpected status: %s", resp.Status)
-	}
-	return nil
+func (c *Client) templateMutation(ctx context.Context, method, path string, tmpl Template) error {
+	_, err := c.send(ctx, request{method: method, path: path, body: tmpl, admin: true})
+	return err
+}
+
+func templatePath(name string) string {
+	return "/admin/templates/" + url.PathEscape(name)
 }
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// WithRootFingerprint pins the CA root with the given SHA-256 fingerprint.
+// Without explicit root certificates the root is downloaded from
+// /root/{fingerprint} on first use and verified against the fingerprint.
+func (c *Client) WithRootFingerprint(fingerprint string) *Client {
+	c.rootFingerprint = NormalizeFingerprint(fingerprint)
+	c.trusted = false
+	return c
+}
+
+// WithRootCertificates trusts only the given roots for TLS connections to the CA.
+func (c *Client) WithRootCertificates(certs []*x509.Certificate) *Client {
+	c.rootCerts = certs
+	c.trusted = false
+	return c
+}
+
+// NormalizeFingerprint lowercases a hex fingerprint and strips separators.
+func NormalizeFingerprint(fingerprint string) string {
+	fp := strings.ToLower(strings.TrimSpace(fingerprint))
+	return strings.NewReplacer(":", "", " ", "").Replace(fp)
+}
+
+// Fingerprint returns the hex-encoded SHA-256 fingerprint of a certificate.
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// ensureTrust installs the pinned root pool on the HTTP transport once.
+func (c *Client) ensureTrust(ctx context.Context) error {
+	if c.rootFingerprint == "" && len(c.rootCerts) == 0 {
+		return nil
+	}
+	c.trustMu.Lock()
+	defer c.trustMu.Unlock()
+	if c.trusted {
+		return nil
+	}
+
+	roots := c.rootCerts
+	if c.rootFingerprint != "" {
+		var root *x509.Certificate
+		for _, cert := range roots {
+			if Fingerprint(cert) == c.rootFingerprint {
+				root = cert
+				break
+			}
+		}
+		if len(roots) > 0 && root == nil {
+			return fmt.Errorf("none of the configured root certificates matches fingerprint %s", c.rootFingerprint)
+		}
+		if root == nil {
+			fetched, err := c.fetchRoot(ctx)
+			if err != nil {
+				return fmt.Errorf("bootstrap root certificate: %w", err)
+			}
+			root = fetched
+		}
+		roots = []*x509.Certificate{root}
+	}
+
+	pool := x509.NewCertPool()
+	for _, cert := range roots {
+		pool.AddCert(cert)
+	}
+	transport := c.baseTransport()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	c.httpClient = &http.Client{Transport: transport, Timeout: c.httpClient.Timeout}
+	c.trusted = true
+	return nil
+}
+
+// fetchRoot downloads the root from /root/{fingerprint} without verifying the
+// TLS chain and accepts it only if its SHA-256 digest matches the fingerprint.
+func (c *Client) fetchRoot(ctx context.Context) (*x509.Certificate, error) {
+	var body struct {
+		CA string `json:"ca"`
+	}
+	r := request{method: http.MethodGet, path: "/root/" + c.rootFingerprint, bootstrap: true}
+	if err := c.sendJSON(ctx, r, &body); err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode([]byte(body.CA))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode root certificate PEM")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	if got := Fingerprint(cert); got != c.rootFingerprint {
+		return nil, fmt.Errorf("root fingerprint mismatch: got %s, want %s", got, c.rootFingerprint)
+	}
+	return cert, nil
+}
+
+// bootstrapClient returns an HTTP client that does not verify the CA's TLS
+// chain. It is only used to download the root before it can be trusted.
+func (c *Client) bootstrapClient() *http.Client {
+	transport := c.baseTransport()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS12}
+	return &http.Client{Transport: transport, Timeout: c.httpClient.Timeout}
+}
+
+// mtlsClient returns an HTTP client that presents cert to the CA while
+// keeping the pinned roots.
+func (c *Client) mtlsClient(cert tls.Certificate) *http.Client {
+	transport := c.baseTransport()
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if transport.TLSClientConfig != nil {
+		cfg = transport.TLSClientConfig.Clone()
+	}
+	cfg.Certificates = []tls.Certificate{cert}
+	transport.TLSClientConfig = cfg
+	return &http.Client{Transport: transport, Timeout: c.httpClient.Timeout}
+}
+
+func (c *Client) baseTransport() *http.Transport {
+	if t, ok := c.httpClient.Transport.(*http.Transport); ok {
+		return t.Clone()
+	}
+	return http.DefaultTransport.(*http.Transport).Clone()
+}
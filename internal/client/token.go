@@ -0,0 +1,269 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// tokenLifetime bounds how long minted tokens are accepted by the CA.
+const tokenLifetime = 5 * time.Minute
+
+// tokenClaims is the JWT payload step-ca expects for admin and one-time tokens.
+type tokenClaims struct {
+	Issuer    string   `json:"iss"`
+	Subject   string   `json:"sub"`
+	Audience  string   `json:"aud"`
+	IssuedAt  int64    `json:"iat"`
+	NotBefore int64    `json:"nbf"`
+	Expiry    int64    `json:"exp"`
+	ID        string   `json:"jti"`
+	SANs      []string `json:"sans,omitempty"`
+}
+
+func newTokenClaims(issuer, subject, audience string, sans []string) (tokenClaims, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return tokenClaims{}, err
+	}
+	now := time.Now()
+	return tokenClaims{
+		Issuer:    issuer,
+		Subject:   subject,
+		Audience:  audience,
+		IssuedAt:  now.Unix(),
+		NotBefore: now.Add(-30 * time.Second).Unix(),
+		Expiry:    now.Add(tokenLifetime).Unix(),
+		ID:        hex.EncodeToString(id),
+		SANs:      sans,
+	}, nil
+}
+
+// authorizeAdmin sets the bearer token for an admin API request. A configured
+// admin token always wins; otherwise a short-lived x5c token is minted from
+// the admin key and certificate with the request URL as audience.
+func (c *Client) authorizeAdmin(req *http.Request) error {
+	if c.adminToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.adminToken)
+		return nil
+	}
+	if c.adminName == "" || c.adminKey == "" || c.adminCert == "" {
+		return fmt.Errorf("no admin credentials configured")
+	}
+	token, err := c.mintAdminToken(audienceFor(req))
+	if err != nil {
+		return fmt.Errorf("mint admin token: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+func (c *Client) mintAdminToken(audience string) (string, error) {
+	if c.adminProvisioner == "" {
+		return "", fmt.Errorf("admin_provisioner must be set to mint admin tokens")
+	}
+	signer, err := c.adminSigner()
+	if err != nil {
+		return "", err
+	}
+	claims, err := newTokenClaims(c.adminProvisioner, c.adminName, audience, nil)
+	if err != nil {
+		return "", err
+	}
+	return signer.sign(claims)
+}
+
+// adminSigner loads the admin key and certificate on first use and caches
+// them for later requests.
+func (c *Client) adminSigner() (*jwtSigner, error) {
+	c.keyMu.Lock()
+	defer c.keyMu.Unlock()
+	if c.adminJWT != nil {
+		return c.adminJWT, nil
+	}
+	material, err := loadKeyMaterial(c.adminKey)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := newJWTSignerFromMaterial(material, "")
+	if err != nil {
+		return nil, err
+	}
+	chain, err := loadCertificates(c.adminCert)
+	if err != nil {
+		return nil, fmt.Errorf("admin certificate: %w", err)
+	}
+	if !certificateNames(chain[0], c.adminName) {
+		return nil, fmt.Errorf("admin certificate is not issued to %q; admin_name must match its common name or a SAN", c.adminName)
+	}
+	if signer, err = signer.withCertificates(chain); err != nil {
+		return nil, fmt.Errorf("admin certificate: %w", err)
+	}
+	c.adminJWT = signer
+	return signer, nil
+}
+
+// signToken returns the one-time token for a /sign request. When a provisioner
+// is configured a fresh token is minted for the CSR, otherwise the static
+// provider token is used.
+func (c *Client) signToken(ctx context.Context, csrPEM string) (string, error) {
+	if c.provisioner == "" {
+		if c.token == "" {
+			return "", fmt.Errorf("no sign credentials configured: set token or a provisioner with its key or password")
+		}
+		return c.token, nil
+	}
+	csr, err := parseCSR(csrPEM)
+	if err != nil {
+		return "", err
+	}
+	sans := csrSANs(csr)
+	subject := csr.Subject.CommonName
+	if subject == "" && len(sans) > 0 {
+		subject = sans[0]
+	}
+	if subject == "" {
+		return "", fmt.Errorf("CSR has neither a common name nor SANs to use as token subject")
+	}
+	if len(sans) == 0 {
+		sans = []string{subject}
+	}
+	return c.mintProvisionerToken(ctx, subject, c.baseURL+"/1.0/sign", sans)
+}
+
+func (c *Client) mintProvisionerToken(ctx context.Context, subject, audience string, sans []string) (string, error) {
+	signer, err := c.provisionerSigner(ctx)
+	if err != nil {
+		return "", err
+	}
+	claims, err := newTokenClaims(c.provisioner, subject, audience, sans)
+	if err != nil {
+		return "", err
+	}
+	return signer.sign(claims)
+}
+
+// provisionerSigner loads the provisioner key on first use. Without a key the
+// provisioner's encrypted key is fetched from the CA and decrypted with the
+// configured password.
+func (c *Client) provisionerSigner(ctx context.Context) (*jwtSigner, error) {
+	c.keyMu.Lock()
+	defer c.keyMu.Unlock()
+	if c.provisionerJWT != nil {
+		return c.provisionerJWT, nil
+	}
+	var (
+		material []byte
+		err      error
+	)
+	if c.provisionerKey != "" {
+		material, err = loadKeyMaterial(c.provisionerKey)
+	} else {
+		material, err = c.encryptedProvisionerKey(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	signer, err := newJWTSignerFromMaterial(material, c.provisionerPassword)
+	if err != nil {
+		return nil, fmt.Errorf("provisioner %q: %w", c.provisioner, err)
+	}
+	c.provisionerJWT = signer
+	return signer, nil
+}
+
+// encryptedProvisionerKey looks up the JWE-encrypted key of the configured
+// provisioner through the public /provisioners endpoint.
+func (c *Client) encryptedProvisionerKey(ctx context.Context) ([]byte, error) {
+	cursor := ""
+	for {
+		query := url.Values{"limit": {"100"}}
+		if cursor != "" {
+			query.Set("cursor", cursor)
+		}
+		var page struct {
+			Provisioners []struct {
+				Name         string `json:"name"`
+				Type         string `json:"type"`
+				EncryptedKey string `json:"encryptedKey"`
+			} `json:"provisioners"`
+			NextCursor string `json:"nextCursor"`
+		}
+		if err := c.sendJSON(ctx, request{method: http.MethodGet, path: "/provisioners", query: query}, &page); err != nil {
+			return nil, err
+		}
+		for _, p := range page.Provisioners {
+			if p.Name != c.provisioner || p.Type != "JWK" {
+				continue
+			}
+			if p.EncryptedKey == "" {
+				return nil, fmt.Errorf("provisioner %q has no encrypted key; configure its private key instead", c.provisioner)
+			}
+			return []byte(p.EncryptedKey), nil
+		}
+		if page.NextCursor == "" {
+			return nil, fmt.Errorf("JWK provisioner %q not found", c.provisioner)
+		}
+		cursor = page.NextCursor
+	}
+}
+
+func parseCSR(csrPEM string) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode([]byte(csrPEM))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode CSR PEM")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse CSR: %w", err)
+	}
+	return csr, nil
+}
+
+// csrSANs flattens all subject alternative names of a CSR into token SANs.
+func csrSANs(csr *x509.CertificateRequest) []string {
+	var sans []string
+	sans = append(sans, csr.DNSNames...)
+	for _, ip := range csr.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	sans = append(sans, csr.EmailAddresses...)
+	for _, u := range csr.URIs {
+		sans = append(sans, u.String())
+	}
+	return sans
+}
+
+// certificateNames reports whether name is the common name or one of the SANs
+// of cert, which is how step-ca matches the token subject to the admin.
+func certificateNames(cert *x509.Certificate, name string) bool {
+	if cert.Subject.CommonName == name {
+		return true
+	}
+	for _, ip := range cert.IPAddresses {
+		if ip.String() == name {
+			return true
+		}
+	}
+	for _, u := range cert.URIs {
+		if u.String() == name {
+			return true
+		}
+	}
+	return slices.Contains(cert.DNSNames, name) || slices.Contains(cert.EmailAddresses, name)
+}
+
+// audienceFor returns the request URL without query parameters.
+func audienceFor(req *http.Request) string {
+	u := *req.URL
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
@@ -0,0 +1,215 @@
+package provider
+
+import (
+	"context"
+	"crypto/x509"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/z0link/terraform-provider-stepca/internal/client"
+)
+
+// certificateDetailsModel holds the attributes parsed from an issued
+// certificate so configurations do not have to decode the PEM themselves.
+type certificateDetailsModel struct {
+	SerialNumber       types.String `tfsdk:"serial_number"`
+	Subject            types.String `tfsdk:"subject"`
+	Issuer             types.String `tfsdk:"issuer"`
+	NotBefore          types.String `tfsdk:"not_before"`
+	NotAfter           types.String `tfsdk:"not_after"`
+	DNSNames           types.List   `tfsdk:"dns_names"`
+	IPAddresses        types.List   `tfsdk:"ip_addresses"`
+	URIs               types.List   `tfsdk:"uris"`
+	EmailAddresses     types.List   `tfsdk:"email_addresses"`
+	KeyUsages          types.List   `tfsdk:"key_usages"`
+	ExtKeyUsages       types.List   `tfsdk:"ext_key_usages"`
+	SHA256Fingerprint  types.String `tfsdk:"sha256_fingerprint"`
+	PublicKeyAlgorithm types.String `tfsdk:"public_key_algorithm"`
+}
+
+// certificateDetailsAttributes returns the computed schema attributes backing
+// certificateDetailsModel. They keep their state values until a new
+// certificate is planned.
+func certificateDetailsAttributes() map[string]schema.Attribute {
+	str := func(description string) schema.Attribute {
+		return schema.StringAttribute{
+			Computed:      true,
+			Description:   description,
+			PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+		}
+	}
+	list := func(description string) schema.Attribute {
+		return schema.ListAttribute{
+			Computed:      true,
+			ElementType:   types.StringType,
+			Description:   description,
+			PlanModifiers: []planmodifier.List{listplanmodifier.UseStateForUnknown()},
+		}
+	}
+	return map[string]schema.Attribute{
+		"serial_number":        str("Serial number of the certificate as lowercase hex."),
+		"subject":              str("Subject distinguished name."),
+		"issuer":               str("Issuer distinguished name."),
+		"not_before":           str("Start of the validity period in RFC 3339 format."),
+		"not_after":            str("End of the validity period in RFC 3339 format."),
+		"dns_names":            list("DNS subject alternative names."),
+		"ip_addresses":         list("IP address subject alternative names."),
+		"uris":                 list("URI subject alternative names."),
+		"email_addresses":      list("Email subject alternative names."),
+		"key_usages":           list("Key usages, for example digital_signature."),
+		"ext_key_usages":       list("Extended key usages, for example server_auth."),
+		"sha256_fingerprint":   str("Lowercase hex SHA-256 fingerprint of the DER certificate."),
+		"public_key_algorithm": str("Public key algorithm, for example ECDSA or RSA."),
+	}
+}
+
+// newCertificateDetails parses pemData into the computed detail attributes.
+// An empty or unparsable certificate yields null details.
+func newCertificateDetails(ctx context.Context, pemData string) (certificateDetailsModel, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	if pemData == "" {
+		return nullCertificateDetails(), diags
+	}
+	cert, err := parseCertificate(pemData)
+	if err != nil {
+		return nullCertificateDetails(), diags
+	}
+
+	ips := make([]string, 0, len(cert.IPAddresses))
+	for _, ip := range cert.IPAddresses {
+		ips = append(ips, ip.String())
+	}
+	uris := make([]string, 0, len(cert.URIs))
+	for _, u := range cert.URIs {
+		uris = append(uris, u.String())
+	}
+
+	details := certificateDetailsModel{
+		SerialNumber:       types.StringValue(strings.ToLower(cert.SerialNumber.Text(16))),
+		Subject:            types.StringValue(cert.Subject.String()),
+		Issuer:             types.StringValue(cert.Issuer.String()),
+		NotBefore:          types.StringValue(cert.NotBefore.UTC().Format(time.RFC3339)),
+		NotAfter:           types.StringValue(cert.NotAfter.UTC().Format(time.RFC3339)),
+		SHA256Fingerprint:  types.StringValue(client.Fingerprint(cert)),
+		PublicKeyAlgorithm: types.StringValue(cert.PublicKeyAlgorithm.String()),
+	}
+	for _, l := range []struct {
+		target *types.List
+		values []string
+	}{
+		{&details.DNSNames, cert.DNSNames},
+		{&details.IPAddresses, ips},
+		{&details.URIs, uris},
+		{&details.EmailAddresses, cert.EmailAddresses},
+		{&details.KeyUsages, keyUsageNames(cert.KeyUsage)},
+		{&details.ExtKeyUsages, extKeyUsageNames(cert.ExtKeyUsage)},
+	} {
+		values := l.values
+		if values == nil {
+			values = []string{}
+		}
+		v, d := types.ListValueFrom(ctx, types.StringType, values)
+		diags.Append(d...)
+		*l.target = v
+	}
+	return details, diags
+}
+
+func nullCertificateDetails() certificateDetailsModel {
+	return certificateDetailsModel{
+		SerialNumber:       types.StringNull(),
+		Subject:            types.StringNull(),
+		Issuer:             types.StringNull(),
+		NotBefore:          types.StringNull(),
+		NotAfter:           types.StringNull(),
+		DNSNames:           types.ListNull(types.StringType),
+		IPAddresses:        types.ListNull(types.StringType),
+		URIs:               types.ListNull(types.StringType),
+		EmailAddresses:     types.ListNull(types.StringType),
+		KeyUsages:          types.ListNull(types.StringType),
+		ExtKeyUsages:       types.ListNull(types.StringType),
+		SHA256Fingerprint:  types.StringNull(),
+		PublicKeyAlgorithm: types.StringNull(),
+	}
+}
+
+// markCertificateDetailsUnknown marks the detail attributes unknown in a plan
+// that is about to receive a new certificate, except the configured ones
+// listed in skip.
+func markCertificateDetailsUnknown(ctx context.Context, plan *tfsdk.Plan, skip ...string) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for name, a := range certificateDetailsAttributes() {
+		if slices.Contains(skip, name) {
+			continue
+		}
+		var unknown attr.Value = types.StringUnknown()
+		if _, ok := a.(schema.ListAttribute); ok {
+			unknown = types.ListUnknown(types.StringType)
+		}
+		diags.Append(plan.SetAttribute(ctx, path.Root(name), unknown)...)
+	}
+	return diags
+}
+
+var keyUsageNamesByBit = []struct {
+	usage x509.KeyUsage
+	name  string
+}{
+	{x509.KeyUsageDigitalSignature, "digital_signature"},
+	{x509.KeyUsageContentCommitment, "content_commitment"},
+	{x509.KeyUsageKeyEncipherment, "key_encipherment"},
+	{x509.KeyUsageDataEncipherment, "data_encipherment"},
+	{x509.KeyUsageKeyAgreement, "key_agreement"},
+	{x509.KeyUsageCertSign, "cert_signing"},
+	{x509.KeyUsageCRLSign, "crl_signing"},
+	{x509.KeyUsageEncipherOnly, "encipher_only"},
+	{x509.KeyUsageDecipherOnly, "decipher_only"},
+}
+
+func keyUsageNames(usage x509.KeyUsage) []string {
+	var names []string
+	for _, u := range keyUsageNamesByBit {
+		if usage&u.usage != 0 {
+			names = append(names, u.name)
+		}
+	}
+	return names
+}
+
+var extKeyUsageNamesByValue = map[x509.ExtKeyUsage]string{
+	x509.ExtKeyUsageAny:                            "any_extended",
+	x509.ExtKeyUsageServerAuth:                     "server_auth",
+	x509.ExtKeyUsageClientAuth:                     "client_auth",
+	x509.ExtKeyUsageCodeSigning:                    "code_signing",
+	x509.ExtKeyUsageEmailProtection:                "email_protection",
+	x509.ExtKeyUsageIPSECEndSystem:                 "ipsec_end_system",
+	x509.ExtKeyUsageIPSECTunnel:                    "ipsec_tunnel",
+	x509.ExtKeyUsageIPSECUser:                      "ipsec_user",
+	x509.ExtKeyUsageTimeStamping:                   "timestamping",
+	x509.ExtKeyUsageOCSPSigning:                    "ocsp_signing",
+	x509.ExtKeyUsageMicrosoftServerGatedCrypto:     "microsoft_server_gated_crypto",
+	x509.ExtKeyUsageNetscapeServerGatedCrypto:      "netscape_server_gated_crypto",
+	x509.ExtKeyUsageMicrosoftCommercialCodeSigning: "microsoft_commercial_code_signing",
+	x509.ExtKeyUsageMicrosoftKernelCodeSigning:     "microsoft_kernel_code_signing",
+}
+
+func extKeyUsageNames(usages []x509.ExtKeyUsage) []string {
+	var names []string
+	for _, u := range usages {
+		if name, ok := extKeyUsageNamesByValue[u]; ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,322 @@
+package provider
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+const (
+	keyAlgorithmRSA     = "RSA"
+	keyAlgorithmECDSA   = "ECDSA"
+	keyAlgorithmEd25519 = "ED25519"
+
+	defaultRSABits    = 2048
+	defaultECDSACurve = "P256"
+)
+
+// generatePrivateKey creates a key for one of the supported algorithms.
+// rsaBits and curve are only used by RSA and ECDSA respectively; zero values
+// select the defaults.
+func generatePrivateKey(algorithm string, rsaBits int, curve string) (crypto.Signer, error) {
+	switch algorithm {
+	case keyAlgorithmRSA:
+		if rsaBits == 0 {
+			rsaBits = defaultRSABits
+		}
+		if rsaBits != 2048 && rsaBits != 3072 && rsaBits != 4096 {
+			return nil, fmt.Errorf("rsa_bits must be 2048, 3072 or 4096, got %d", rsaBits)
+		}
+		return rsa.GenerateKey(rand.Reader, rsaBits)
+	case keyAlgorithmECDSA:
+		if curve == "" {
+			curve = defaultECDSACurve
+		}
+		switch curve {
+		case "P256":
+			return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		case "P384":
+			return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		}
+		return nil, fmt.Errorf("ecdsa_curve must be P256 or P384, got %q", curve)
+	case keyAlgorithmEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	}
+	return nil, fmt.Errorf("algorithm must be RSA, ECDSA or ED25519, got %q", algorithm)
+}
+
+// encodePrivateKeyPEM encodes key as an unencrypted PKCS#8 PEM block.
+func encodePrivateKeyPEM(key crypto.Signer) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
+
+// parsePrivateKeyPEM decodes a PKCS#8, SEC 1 or PKCS#1 private key.
+func parsePrivateKeyPEM(keyPEM string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(keyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode private key PEM")
+	}
+	var (
+		key any
+		err error
+	)
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported private key PEM type %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// publicKeyPEM returns the PKIX PEM encoding of the public key and the hex
+// SHA-256 fingerprint of its DER form.
+func publicKeyPEM(key crypto.Signer) (string, string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256(der)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), hex.EncodeToString(sum[:]), nil
+}
+
+// parseCertificateRequestPEM decodes a PEM encoded CSR.
+func parseCertificateRequestPEM(csrPEM string) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode([]byte(csrPEM))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode CSR PEM")
+	}
+	return x509.ParseCertificateRequest(block.Bytes)
+}
+
+// csrRequest collects everything encoded into a generated CSR.
+type csrRequest struct {
+	Subject        pkix.Name
+	DNSNames       []string
+	IPAddresses    []string
+	URIs           []string
+	EmailAddresses []string
+	KeyUsages      []string
+	ExtKeyUsages   []string
+}
+
+// buildCSR creates a PEM encoded CSR for req signed with key.
+func buildCSR(key crypto.Signer, req csrRequest) (string, error) {
+	tmpl := &x509.CertificateRequest{
+		Subject:        req.Subject,
+		DNSNames:       req.DNSNames,
+		EmailAddresses: req.EmailAddresses,
+	}
+	for _, v := range req.IPAddresses {
+		ip := net.ParseIP(v)
+		if ip == nil {
+			return "", fmt.Errorf("invalid IP address %q", v)
+		}
+		tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+	}
+	for _, v := range req.URIs {
+		u, err := url.Parse(v)
+		if err != nil || u.Scheme == "" {
+			return "", fmt.Errorf("invalid URI %q", v)
+		}
+		tmpl.URIs = append(tmpl.URIs, u)
+	}
+	if tmpl.Subject.CommonName == "" && len(tmpl.DNSNames)+len(tmpl.IPAddresses)+len(tmpl.URIs)+len(tmpl.EmailAddresses) == 0 {
+		return "", fmt.Errorf("a CSR needs a common name or at least one subject alternative name")
+	}
+	if len(req.KeyUsages) > 0 {
+		ext, err := keyUsageExtension(req.KeyUsages)
+		if err != nil {
+			return "", err
+		}
+		tmpl.ExtraExtensions = append(tmpl.ExtraExtensions, ext)
+	}
+	if len(req.ExtKeyUsages) > 0 {
+		ext, err := extKeyUsageExtension(req.ExtKeyUsages)
+		if err != nil {
+			return "", err
+		}
+		tmpl.ExtraExtensions = append(tmpl.ExtraExtensions, ext)
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, tmpl, key)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})), nil
+}
+
+var (
+	oidExtensionKeyUsage    = asn1.ObjectIdentifier{2, 5, 29, 15}
+	oidExtensionExtKeyUsage = asn1.ObjectIdentifier{2, 5, 29, 37}
+
+	// csrExtKeyUsageOIDs are the extended key usages that can be requested
+	// in a generated CSR, named like the ext_key_usages attribute.
+	csrExtKeyUsageOIDs = map[string]asn1.ObjectIdentifier{
+		"any_extended":     {2, 5, 29, 37, 0},
+		"server_auth":      {1, 3, 6, 1, 5, 5, 7, 3, 1},
+		"client_auth":      {1, 3, 6, 1, 5, 5, 7, 3, 2},
+		"code_signing":     {1, 3, 6, 1, 5, 5, 7, 3, 3},
+		"email_protection": {1, 3, 6, 1, 5, 5, 7, 3, 4},
+		"ipsec_end_system": {1, 3, 6, 1, 5, 5, 7, 3, 5},
+		"ipsec_tunnel":     {1, 3, 6, 1, 5, 5, 7, 3, 6},
+		"ipsec_user":       {1, 3, 6, 1, 5, 5, 7, 3, 7},
+		"timestamping":     {1, 3, 6, 1, 5, 5, 7, 3, 8},
+		"ocsp_signing":     {1, 3, 6, 1, 5, 5, 7, 3, 9},
+	}
+)
+
+// keyUsageExtension encodes key usage names as a critical keyUsage extension.
+func keyUsageExtension(names []string) (pkix.Extension, error) {
+	var usage x509.KeyUsage
+	for _, name := range names {
+		found := false
+		for _, u := range keyUsageNamesByBit {
+			if u.name == name {
+				usage |= u.usage
+				found = true
+				break
+			}
+		}
+		if !found {
+			return pkix.Extension{}, fmt.Errorf("unknown key usage %q", name)
+		}
+	}
+	// Bit 0 of the BIT STRING is digitalSignature, the most significant bit
+	// of the first byte.
+	var bits [2]byte
+	length := 0
+	for i := 0; i < 9; i++ {
+		if usage&(1<<i) != 0 {
+			bits[i/8] |= 0x80 >> (i % 8)
+			length = i + 1
+		}
+	}
+	value, err := asn1.Marshal(asn1.BitString{Bytes: bits[:(length+7)/8], BitLength: length})
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	return pkix.Extension{Id: oidExtensionKeyUsage, Critical: true, Value: value}, nil
+}
+
+// extKeyUsageExtension encodes extended key usage names as an extension.
+func extKeyUsageExtension(names []string) (pkix.Extension, error) {
+	oids := make([]asn1.ObjectIdentifier, 0, len(names))
+	for _, name := range names {
+		oid, ok := csrExtKeyUsageOIDs[name]
+		if !ok {
+			return pkix.Extension{}, fmt.Errorf("unknown extended key usage %q", name)
+		}
+		oids = append(oids, oid)
+	}
+	value, err := asn1.Marshal(oids)
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	return pkix.Extension{Id: oidExtensionExtKeyUsage, Value: value}, nil
+}
+
+// parseDistinguishedName parses an RFC 4514 style subject such as
+// "CN=svc.example.com,O=Example,C=US", the format the subject attribute is
+// reported in. Multi-valued attributes may repeat, for example "OU=a,OU=b".
+func parseDistinguishedName(dn string) (pkix.Name, error) {
+	var name pkix.Name
+	if strings.TrimSpace(dn) == "" {
+		return name, nil
+	}
+	for _, rdn := range splitUnescaped(dn, ",+") {
+		key, value, ok := strings.Cut(rdn, "=")
+		if !ok {
+			return name, fmt.Errorf("invalid subject component %q: expected KEY=value", rdn)
+		}
+		value = unescapeDNValue(strings.TrimSpace(value))
+		switch strings.ToUpper(strings.TrimSpace(key)) {
+		case "CN":
+			name.CommonName = value
+		case "SERIALNUMBER":
+			name.SerialNumber = value
+		case "O":
+			name.Organization = append(name.Organization, value)
+		case "OU":
+			name.OrganizationalUnit = append(name.OrganizationalUnit, value)
+		case "C":
+			name.Country = append(name.Country, value)
+		case "ST", "S":
+			name.Province = append(name.Province, value)
+		case "L":
+			name.Locality = append(name.Locality, value)
+		case "STREET":
+			name.StreetAddress = append(name.StreetAddress, value)
+		case "POSTALCODE":
+			name.PostalCode = append(name.PostalCode, value)
+		default:
+			return name, fmt.Errorf("unsupported subject attribute %q", key)
+		}
+	}
+	return name, nil
+}
+
+// splitUnescaped splits s at any of seps that is not preceded by a backslash.
+func splitUnescaped(s, seps string) []string {
+	var parts []string
+	var cur strings.Builder
+	escaped := false
+	for _, r := range s {
+		switch {
+		case escaped:
+			cur.WriteRune('\\')
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case strings.ContainsRune(seps, r):
+			parts = append(parts, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	return append(parts, cur.String())
+}
+
+func unescapeDNValue(v string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range v {
+		if !escaped && r == '\\' {
+			escaped = true
+			continue
+		}
+		escaped = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,185 @@
+package provider
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGeneratePrivateKey(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		algorithm string
+		rsaBits   int
+		curve     string
+		check     func(any) bool
+		wantErr   bool
+	}{
+		{name: "rsa default", algorithm: "RSA", check: func(k any) bool { return k.(*rsa.PrivateKey).N.BitLen() == 2048 }},
+		{name: "rsa 3072", algorithm: "RSA", rsaBits: 3072, check: func(k any) bool { return k.(*rsa.PrivateKey).N.BitLen() == 3072 }},
+		{name: "ecdsa default", algorithm: "ECDSA", check: func(k any) bool { return k.(*ecdsa.PrivateKey).Curve.Params().Name == "P-256" }},
+		{name: "ecdsa p384", algorithm: "ECDSA", curve: "P384", check: func(k any) bool { return k.(*ecdsa.PrivateKey).Curve.Params().Name == "P-384" }},
+		{name: "ed25519", algorithm: "ED25519", check: func(k any) bool { _, ok := k.(ed25519.PrivateKey); return ok }},
+		{name: "rsa 1024", algorithm: "RSA", rsaBits: 1024, wantErr: true},
+		{name: "ecdsa p521", algorithm: "ECDSA", curve: "P521", wantErr: true},
+		{name: "dsa", algorithm: "DSA", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := generatePrivateKey(tc.algorithm, tc.rsaBits, tc.curve)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("generate: %v", err)
+			}
+			keyPEM, err := encodePrivateKeyPEM(key)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			parsed, err := parsePrivateKeyPEM(keyPEM)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if !tc.check(parsed) {
+				t.Fatalf("unexpected key %T", parsed)
+			}
+		})
+	}
+}
+
+func TestParseDistinguishedName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		dn      string
+		want    pkix.Name
+		wantErr bool
+	}{
+		{name: "empty", dn: ""},
+		{name: "common name", dn: "CN=svc.example.com", want: pkix.Name{CommonName: "svc.example.com"}},
+		{
+			name: "full subject",
+			dn:   "CN=svc, OU=ops,OU=web,O=Example,L=Berlin,ST=BE,C=DE,STREET=Main 1,POSTALCODE=10115,SERIALNUMBER=42",
+			want: pkix.Name{
+				CommonName:         "svc",
+				OrganizationalUnit: []string{"ops", "web"},
+				Organization:       []string{"Example"},
+				Locality:           []string{"Berlin"},
+				Province:           []string{"BE"},
+				Country:            []string{"DE"},
+				StreetAddress:      []string{"Main 1"},
+				PostalCode:         []string{"10115"},
+				SerialNumber:       "42",
+			},
+		},
+		{name: "escaped comma", dn: `CN=svc,O=Example\, Inc.`, want: pkix.Name{CommonName: "svc", Organization: []string{"Example, Inc."}}},
+		{name: "lowercase keys", dn: "cn=svc,o=Example", want: pkix.Name{CommonName: "svc", Organization: []string{"Example"}}},
+		{name: "missing value", dn: "CN", wantErr: true},
+		{name: "unknown attribute", dn: "UID=svc", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseDistinguishedName(tc.dn)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error=%t got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("unexpected name %#v", got)
+			}
+		})
+	}
+
+	// The reported subject of a certificate parses back to the same name.
+	name := pkix.Name{CommonName: "svc, primary", Organization: []string{"Example"}, Country: []string{"US"}}
+	got, err := parseDistinguishedName(name.String())
+	if err != nil {
+		t.Fatalf("parse %q: %v", name.String(), err)
+	}
+	if got.String() != name.String() {
+		t.Fatalf("round trip changed subject: %q != %q", got.String(), name.String())
+	}
+}
+
+func TestBuildCSR(t *testing.T) {
+	t.Parallel()
+
+	key, err := generatePrivateKey("ECDSA", 0, "")
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	csrPEM, err := buildCSR(key, csrRequest{
+		Subject:        pkix.Name{CommonName: "svc.example.com"},
+		DNSNames:       []string{"svc.example.com"},
+		IPAddresses:    []string{"10.0.0.1"},
+		URIs:           []string{"spiffe://example.com/svc"},
+		EmailAddresses: []string{"ops@example.com"},
+		KeyUsages:      []string{"digital_signature", "key_encipherment", "decipher_only"},
+		ExtKeyUsages:   []string{"server_auth", "client_auth"},
+	})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	block, _ := pem.Decode([]byte(csrPEM))
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		t.Fatalf("parse csr: %v", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		t.Fatalf("csr signature: %v", err)
+	}
+	if csr.Subject.CommonName != "svc.example.com" || len(csr.DNSNames) != 1 || len(csr.IPAddresses) != 1 || len(csr.URIs) != 1 || len(csr.EmailAddresses) != 1 {
+		t.Fatalf("unexpected csr contents: %+v", csr)
+	}
+
+	// Issue a certificate carrying the requested extensions to check that Go
+	// decodes them to the requested usages.
+	tmpl := &x509.Certificate{
+		SerialNumber:    big.NewInt(1),
+		NotBefore:       time.Now(),
+		NotAfter:        time.Now().Add(time.Hour),
+		ExtraExtensions: csr.Extensions,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, csr.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create cert: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse cert: %v", err)
+	}
+	if want := x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageDecipherOnly; cert.KeyUsage != want {
+		t.Fatalf("unexpected key usage %b, want %b", cert.KeyUsage, want)
+	}
+	if !reflect.DeepEqual(cert.ExtKeyUsage, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}) {
+		t.Fatalf("unexpected ext key usage %v", cert.ExtKeyUsage)
+	}
+
+	for name, req := range map[string]csrRequest{
+		"no names":          {},
+		"bad ip":            {DNSNames: []string{"a"}, IPAddresses: []string{"not-an-ip"}},
+		"bad uri":           {DNSNames: []string{"a"}, URIs: []string{"no-scheme"}},
+		"unknown usage":     {DNSNames: []string{"a"}, KeyUsages: []string{"signing"}},
+		"unknown ext usage": {DNSNames: []string{"a"}, ExtKeyUsages: []string{"web"}},
+	} {
+		if _, err := buildCSR(key, req); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/z0link/terraform-provider-stepca/internal/client"
+)
+
+var _ datasource.DataSource = &authorityPolicyDataSource{}
+
+func NewAuthorityPolicyDataSource() datasource.DataSource {
+	return &authorityPolicyDataSource{}
+}
+
+type authorityPolicyDataSource struct {
+	client authorityPolicyGetter
+}
+
+type authorityPolicyDataSourceModel struct {
+	Exists types.Bool       `tfsdk:"exists"`
+	X509   *x509PolicyModel `tfsdk:"x509"`
+	SSH    *sshPolicyModel  `tfsdk:"ssh"`
+}
+
+func (d *authorityPolicyDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = "stepca_authority_policy"
+}
+
+func (d *authorityPolicyDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	names := func(attrs ...string) schema.Attribute {
+		attributes := map[string]schema.Attribute{}
+		for _, name := range attrs {
+			attributes[name] = schema.ListAttribute{Computed: true, ElementType: types.StringType}
+		}
+		return schema.SingleNestedAttribute{Computed: true, Attributes: attributes}
+	}
+	rules := func(attrs ...string) schema.Attribute {
+		return schema.SingleNestedAttribute{
+			Computed:   true,
+			Attributes: map[string]schema.Attribute{"allow": names(attrs...), "deny": names(attrs...)},
+		}
+	}
+	resp.Schema = schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"exists": schema.BoolAttribute{Computed: true},
+			"x509": schema.SingleNestedAttribute{
+				Computed: true,
+				Attributes: map[string]schema.Attribute{
+					"allow_wildcard_names": schema.BoolAttribute{Computed: true},
+					"allow":                names("common_names", "dns", "ips", "emails", "uris"),
+					"deny":                 names("common_names", "dns", "ips", "emails", "uris"),
+				},
+			},
+			"ssh": schema.SingleNestedAttribute{
+				Computed: true,
+				Attributes: map[string]schema.Attribute{
+					"user": rules("emails", "principals"),
+					"host": rules("dns", "ips", "principals"),
+				},
+			},
+		},
+	}
+}
+
+func (d *authorityPolicyDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	if c, ok := req.ProviderData.(*client.Client); ok {
+		d.client = c
+	}
+}
+
+func (d *authorityPolicyDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.client == nil {
+		resp.Diagnostics.AddError("provider not configured", "missing client")
+		return
+	}
+
+	p, err := d.client.GetAuthorityPolicy(ctx)
+	if err != nil {
+		resp.Diagnostics.Append(clientErrorDiagnostic("read failed", "authority policy", err))
+		return
+	}
+
+	data := authorityPolicyDataSourceModel{Exists: types.BoolValue(p != nil)}
+	if p != nil {
+		data.X509 = x509PolicyFromClient(nil, p.X509)
+		data.SSH = sshPolicyFromClient(nil, p.SSH)
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+
+	"github.com/z0link/terraform-provider-stepca/internal/client"
+)
+
+func TestAuthorityPolicyDataSourceRead(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var schemaResp datasource.SchemaResponse
+	NewAuthorityPolicyDataSource().Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+	if diags := schemaResp.Schema.ValidateImplementation(ctx); diags.HasError() {
+		t.Fatalf("invalid schema: %v", diags)
+	}
+
+	tests := []struct {
+		name   string
+		policy *client.Policy
+		check  func(authorityPolicyDataSourceModel) bool
+	}{
+		{
+			name:   "no policy",
+			policy: nil,
+			check: func(m authorityPolicyDataSourceModel) bool {
+				return !m.Exists.ValueBool() && m.X509 == nil && m.SSH == nil
+			},
+		},
+		{
+			name: "policy",
+			policy: &client.Policy{SSH: &client.SSHPolicy{Host: &client.SSHHostPolicy{
+				Allow: &client.SSHHostNames{DNS: []string{"*.example.com"}},
+			}}},
+			check: func(m authorityPolicyDataSourceModel) bool {
+				return m.Exists.ValueBool() && m.X509 == nil && m.SSH.User == nil &&
+					m.SSH.Host.Deny == nil && len(m.SSH.Host.Allow.DNS.Elements()) == 1 && m.SSH.Host.Allow.IPs.IsNull()
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &authorityPolicyDataSource{client: &fakeAuthorityPolicyClient{policy: tc.policy}}
+			resp := datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			d.Read(ctx, datasource.ReadRequest{}, &resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("read: %v", resp.Diagnostics)
+			}
+			var got authorityPolicyDataSourceModel
+			resp.State.Get(ctx, &got)
+			if !tc.check(got) {
+				t.Fatalf("unexpected state: %#v", got)
+			}
+		})
+	}
+}
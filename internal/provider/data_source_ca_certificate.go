@@ -52,7 +52,7 @@ func (d *caCertificateDataSource) Read(ctx context.Context, req datasource.ReadR
 	}
 	pem, err := d.client.RootCertificate(ctx)
 	if err != nil {
-		resp.Diagnostics.AddError("fetch failed", err.Error())
+		resp.Diagnostics.Append(clientErrorDiagnostic("fetch failed", "root certificate", err))
 		return
 	}
 	data := caCertificateDataSourceModel{Certificate: types.StringValue(string(pem))}
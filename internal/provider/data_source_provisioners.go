@@ -68,7 +68,7 @@ func (d *provisionersDataSource) Read(ctx context.Context, req datasource.ReadRe
 
 	items, err := d.client.ListProvisioners(ctx)
 	if err != nil {
-		resp.Diagnostics.AddError("failed to list provisioners", err.Error())
+		resp.Diagnostics.Append(clientErrorDiagnostic("failed to list provisioners", "provisioner", err))
 		return
 	}
 
@@ -53,7 +53,7 @@ func (d *versionDataSource) Read(ctx context.Context, req datasource.ReadRequest
 
 	version, err := d.client.Version(ctx)
 	if err != nil {
-		resp.Diagnostics.AddError("version fetch failed", err.Error())
+		resp.Diagnostics.Append(clientErrorDiagnostic("version fetch failed", "version", err))
 		return
 	}
 
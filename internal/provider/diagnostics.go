@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+
+	"github.com/z0link/terraform-provider-stepca/internal/client"
+)
+
+// clientErrorDiagnostic converts a client error into an error diagnostic. Errors
+// returned by step-ca get a summary describing what went wrong with the given
+// object (for example "provisioner already exists"); anything else falls back
+// to summary with the raw error as detail.
+func clientErrorDiagnostic(summary, object string, err error) diag.Diagnostic {
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		return diag.NewErrorDiagnostic(summary, err.Error())
+	}
+
+	message := apiErr.Message
+	switch apiErr.StatusCode {
+	case http.StatusConflict:
+		summary = fmt.Sprintf("%s already exists", object)
+	case http.StatusNotFound:
+		summary = fmt.Sprintf("%s not found", object)
+	case http.StatusUnauthorized:
+		summary = "unauthorized: " + firstNonEmpty(message, "check the provider's admin or provisioner credentials")
+	case http.StatusForbidden:
+		summary = "forbidden: " + firstNonEmpty(message, "the configured credentials lack permission for this operation")
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		summary = fmt.Sprintf("invalid %s request", object)
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		summary = "step-ca unavailable"
+	default:
+		if apiErr.StatusCode >= 500 {
+			summary = "step-ca server error"
+		}
+	}
+
+	var detail strings.Builder
+	if message != "" {
+		detail.WriteString(message)
+		if apiErr.Detail != "" && apiErr.Detail != message {
+			fmt.Fprintf(&detail, ": %s", apiErr.Detail)
+		}
+		detail.WriteString("\n\n")
+	}
+	fmt.Fprintf(&detail, "Request: %s %s\nStatus: %s", apiErr.Method, apiErr.Path, apiErr.Status)
+	if apiErr.RequestID != "" {
+		fmt.Fprintf(&detail, "\nRequest ID: %s", apiErr.RequestID)
+	}
+	return diag.NewErrorDiagnostic(summary, detail.String())
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,55 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/z0link/terraform-provider-stepca/internal/client"
+)
+
+func TestClientErrorDiagnostic(t *testing.T) {
+	t.Parallel()
+
+	apiErr := func(code int, message string) error {
+		return fmt.Errorf("wrapped: %w", &client.APIError{
+			StatusCode: code,
+			Status:     fmt.Sprintf("%d %s", code, http.StatusText(code)),
+			Message:    message,
+			Method:     http.MethodPost,
+			Path:       "/admin/provisioners",
+			RequestID:  "req-1",
+		})
+	}
+
+	tests := []struct {
+		name    string
+		err     error
+		summary string
+	}{
+		{name: "conflict", err: apiErr(http.StatusConflict, "provisioner acme already exists"), summary: "provisioner already exists"},
+		{name: "unauthorized", err: apiErr(http.StatusUnauthorized, "admin token expired"), summary: "unauthorized: admin token expired"},
+		{name: "forbidden without message", err: apiErr(http.StatusForbidden, ""), summary: "forbidden: the configured credentials lack permission for this operation"},
+		{name: "bad request", err: apiErr(http.StatusBadRequest, "invalid type"), summary: "invalid provisioner request"},
+		{name: "unavailable", err: apiErr(http.StatusServiceUnavailable, ""), summary: "step-ca unavailable"},
+		{name: "server error", err: apiErr(http.StatusInternalServerError, "boom"), summary: "step-ca server error"},
+		{name: "transport error", err: fmt.Errorf("dial tcp: connection refused"), summary: "create failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := clientErrorDiagnostic("create failed", "provisioner", tt.err)
+			if d.Summary() != tt.summary {
+				t.Fatalf("unexpected summary: %q", d.Summary())
+			}
+		})
+	}
+
+	d := clientErrorDiagnostic("create failed", "provisioner", apiErr(http.StatusConflict, "provisioner acme already exists"))
+	for _, want := range []string{"provisioner acme already exists", "Request: POST /admin/provisioners", "Status: 409 Conflict", "Request ID: req-1"} {
+		if !strings.Contains(d.Detail(), want) {
+			t.Fatalf("detail %q missing %q", d.Detail(), want)
+		}
+	}
+}
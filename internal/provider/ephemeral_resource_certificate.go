@@ -0,0 +1,217 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/z0link/terraform-provider-stepca/internal/client"
+)
+
+var (
+	_ ephemeral.EphemeralResource                   = &ephemeralCertificateResource{}
+	_ ephemeral.EphemeralResourceWithConfigure      = &ephemeralCertificateResource{}
+	_ ephemeral.EphemeralResourceWithValidateConfig = &ephemeralCertificateResource{}
+)
+
+// NewEphemeralCertificateResource returns the ephemeral stepca_certificate,
+// which issues a certificate for a key that only exists in memory.
+func NewEphemeralCertificateResource() ephemeral.EphemeralResource {
+	return &ephemeralCertificateResource{}
+}
+
+type ephemeralCertificateClient interface {
+	Sign(ctx context.Context, csr string) (*client.SignResult, error)
+}
+
+type ephemeralCertificateResource struct {
+	client ephemeralCertificateClient
+}
+
+type ephemeralCertificateModel struct {
+	Algorithm        types.String `tfsdk:"algorithm"`
+	RSABits          types.Int64  `tfsdk:"rsa_bits"`
+	ECDSACurve       types.String `tfsdk:"ecdsa_curve"`
+	Subject          types.String `tfsdk:"subject"`
+	DNSNames         types.List   `tfsdk:"dns_names"`
+	IPAddresses      types.List   `tfsdk:"ip_addresses"`
+	URIs             types.List   `tfsdk:"uris"`
+	EmailAddresses   types.List   `tfsdk:"email_addresses"`
+	PrivateKeyPEM    types.String `tfsdk:"private_key_pem"`
+	Cert             types.String `tfsdk:"certificate"`
+	CACertificate    types.String `tfsdk:"ca_certificate"`
+	CertificateChain types.List   `tfsdk:"certificate_chain"`
+	FullChainPEM     types.String `tfsdk:"full_chain_pem"`
+	SerialNumber     types.String `tfsdk:"serial_number"`
+	NotAfter         types.String `tfsdk:"not_after"`
+}
+
+func (r *ephemeralCertificateResource) Metadata(ctx context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = "stepca_certificate"
+}
+
+func (r *ephemeralCertificateResource) Schema(ctx context.Context, req ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	list := func(description string) schema.Attribute {
+		return schema.ListAttribute{Optional: true, ElementType: types.StringType, Description: description}
+	}
+	computed := func(description string) schema.Attribute {
+		return schema.StringAttribute{Computed: true, Description: description}
+	}
+	resp.Schema = schema.Schema{
+		Description: "Generates a private key in memory and has step-ca sign a certificate for it. Nothing is stored in plan or state.",
+		Attributes: map[string]schema.Attribute{
+			"algorithm": schema.StringAttribute{
+				Optional:    true,
+				Description: "Key algorithm: RSA, ECDSA (default) or ED25519.",
+			},
+			"rsa_bits": schema.Int64Attribute{
+				Optional:    true,
+				Description: "RSA key size: 2048 (default), 3072 or 4096.",
+			},
+			"ecdsa_curve": schema.StringAttribute{
+				Optional:    true,
+				Description: "ECDSA curve: P256 (default) or P384.",
+			},
+			"subject": schema.StringAttribute{
+				Optional:    true,
+				Description: "Subject distinguished name such as \"CN=svc.example.com,O=Example\".",
+			},
+			"dns_names":       list("DNS subject alternative names."),
+			"ip_addresses":    list("IP address subject alternative names."),
+			"uris":            list("URI subject alternative names."),
+			"email_addresses": list("Email subject alternative names."),
+			"private_key_pem": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The generated private key in PKCS#8 PEM format.",
+			},
+			"certificate":    computed("The PEM encoded leaf certificate."),
+			"ca_certificate": computed("The PEM encoded issuing CA certificate."),
+			"certificate_chain": schema.ListAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "PEM encoded certificates from the leaf up to the issuing CA.",
+			},
+			"full_chain_pem": computed("The certificate chain concatenated into one PEM bundle."),
+			"serial_number":  computed("Serial number of the certificate as lowercase hex."),
+			"not_after":      computed("End of the validity period in RFC 3339 format."),
+		},
+	}
+}
+
+func (r *ephemeralCertificateResource) Configure(ctx context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	if c, ok := req.ProviderData.(*client.Client); ok {
+		r.client = c
+	}
+}
+
+func (r *ephemeralCertificateResource) ValidateConfig(ctx context.Context, req ephemeral.ValidateConfigRequest, resp *ephemeral.ValidateConfigResponse) {
+	var data ephemeralCertificateModel
+	diags := req.Config.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(validatePrivateKeyConfig(data.keyConfig())...)
+	if !data.Subject.IsUnknown() {
+		if _, err := parseDistinguishedName(data.Subject.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("subject"), "invalid subject", err.Error())
+		}
+	}
+}
+
+// keyConfig returns the key settings in stepca_private_key form, with the
+// algorithm defaulting to ECDSA.
+func (m ephemeralCertificateModel) keyConfig() privateKeyResourceModel {
+	algorithm := m.Algorithm
+	if algorithm.IsNull() {
+		algorithm = types.StringValue(keyAlgorithmECDSA)
+	}
+	return privateKeyResourceModel{
+		Algorithm:  algorithm,
+		RSABits:    m.RSABits,
+		ECDSACurve: m.ECDSACurve,
+	}
+}
+
+func (r *ephemeralCertificateResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	var data ephemeralCertificateModel
+	diags := req.Config.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if r.client == nil {
+		resp.Diagnostics.AddError("provider not configured", "missing client")
+		return
+	}
+	resp.Diagnostics.Append(r.issue(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	diags = resp.Result.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
+
+// issue generates a key, signs a CSR for it and fills in the computed
+// attributes of data.
+func (r *ephemeralCertificateResource) issue(ctx context.Context, data *ephemeralCertificateModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	keyConfig := data.keyConfig()
+	key, err := generatePrivateKey(keyConfig.Algorithm.ValueString(), int(keyConfig.RSABits.ValueInt64()), keyConfig.ECDSACurve.ValueString())
+	if err != nil {
+		diags.AddError("key generation failed", err.Error())
+		return diags
+	}
+	keyPEM, err := encodePrivateKeyPEM(key)
+	if err != nil {
+		diags.AddError("key generation failed", err.Error())
+		return diags
+	}
+	csr, csrDiags := csrResourceModel{
+		PrivateKeyPEM:  types.StringValue(keyPEM),
+		Subject:        data.Subject,
+		DNSNames:       data.DNSNames,
+		IPAddresses:    data.IPAddresses,
+		URIs:           data.URIs,
+		EmailAddresses: data.EmailAddresses,
+	}.build(ctx)
+	diags.Append(csrDiags...)
+	if diags.HasError() {
+		return diags
+	}
+
+	result, err := r.client.Sign(ctx, csr)
+	if err != nil {
+		diags.Append(clientErrorDiagnostic("sign failed", "certificate", err))
+		return diags
+	}
+	chain := result.Chain()
+	data.PrivateKeyPEM = types.StringValue(keyPEM)
+	data.Cert = types.StringValue(result.Certificate)
+	data.CACertificate = types.StringValue(result.CA)
+	data.FullChainPEM = types.StringValue(joinPEM(chain))
+	list, listDiags := types.ListValueFrom(ctx, types.StringType, chain)
+	diags.Append(listDiags...)
+	data.CertificateChain = list
+
+	data.SerialNumber = types.StringNull()
+	data.NotAfter = types.StringNull()
+	if cert, err := parseCertificate(result.Certificate); err == nil {
+		data.SerialNumber = types.StringValue(strings.ToLower(cert.SerialNumber.Text(16)))
+		data.NotAfter = types.StringValue(cert.NotAfter.UTC().Format(time.RFC3339))
+	}
+	return diags
+}
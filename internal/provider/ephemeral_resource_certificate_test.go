@@ -0,0 +1,91 @@
+package provider
+
+import (
+	"context"
+	"crypto"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestEphemeralCertificateOpen(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	certPEM := testCertificate(t, 4660, "svc.test")
+	fake := &fakeCertificateClient{signPEM: certPEM}
+	r := &ephemeralCertificateResource{client: fake}
+	var schemaResp ephemeral.SchemaResponse
+	r.Schema(ctx, ephemeral.SchemaRequest{}, &schemaResp)
+
+	dnsNames, _ := types.ListValueFrom(ctx, types.StringType, []string{"svc.test"})
+	input := tfsdk.Plan{Schema: schemaResp.Schema}
+	if diags := input.Set(ctx, &ephemeralCertificateModel{
+		Algorithm:        types.StringNull(),
+		RSABits:          types.Int64Null(),
+		ECDSACurve:       types.StringNull(),
+		Subject:          types.StringValue("CN=svc.test"),
+		DNSNames:         dnsNames,
+		IPAddresses:      types.ListNull(types.StringType),
+		URIs:             types.ListNull(types.StringType),
+		EmailAddresses:   types.ListNull(types.StringType),
+		PrivateKeyPEM:    types.StringNull(),
+		Cert:             types.StringNull(),
+		CACertificate:    types.StringNull(),
+		CertificateChain: types.ListNull(types.StringType),
+		FullChainPEM:     types.StringNull(),
+		SerialNumber:     types.StringNull(),
+		NotAfter:         types.StringNull(),
+	}); diags.HasError() {
+		t.Fatalf("set config: %v", diags)
+	}
+
+	resp := ephemeral.OpenResponse{Result: tfsdk.EphemeralResultData{Schema: schemaResp.Schema}}
+	r.Open(ctx, ephemeral.OpenRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: input.Raw}}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("open: %v", resp.Diagnostics)
+	}
+
+	var got ephemeralCertificateModel
+	resp.Result.Get(ctx, &got)
+	if got.Cert.ValueString() != certPEM || got.SerialNumber.ValueString() != "1234" {
+		t.Fatalf("unexpected certificate attributes: %+v", got)
+	}
+	if len(got.CertificateChain.Elements()) != 2 || got.NotAfter.IsNull() {
+		t.Fatalf("unexpected chain attributes: %+v", got)
+	}
+
+	csr, err := parseCertificateRequestPEM(fake.signCSR)
+	if err != nil {
+		t.Fatalf("parse csr: %v", err)
+	}
+	if csr.Subject.CommonName != "svc.test" || len(csr.DNSNames) != 1 {
+		t.Fatalf("unexpected csr: %+v", csr)
+	}
+	key, err := parsePrivateKeyPEM(got.PrivateKeyPEM.ValueString())
+	if err != nil {
+		t.Fatalf("parse key: %v", err)
+	}
+	if pub, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool }); !ok || !pub.Equal(csr.PublicKey) {
+		t.Fatal("the returned private key does not match the signed CSR")
+	}
+}
+
+func TestEphemeralCertificateKeyConfig(t *testing.T) {
+	t.Parallel()
+
+	data := ephemeralCertificateModel{
+		Algorithm:  types.StringNull(),
+		RSABits:    types.Int64Null(),
+		ECDSACurve: types.StringValue("P384"),
+	}
+	if diags := validatePrivateKeyConfig(data.keyConfig()); diags.HasError() {
+		t.Fatalf("the algorithm must default to ECDSA: %v", diags)
+	}
+	data.RSABits = types.Int64Value(4096)
+	if diags := validatePrivateKeyConfig(data.keyConfig()); !diags.HasError() {
+		t.Fatal("expected rsa_bits to be rejected for the default ECDSA key")
+	}
+}
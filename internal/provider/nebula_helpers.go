@@ -0,0 +1,137 @@
+package provider
+
+import (
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"time"
+)
+
+// PEM block types of Nebula certificates, as written by `nebula-cert`.
+const (
+	nebulaCertificateBlock   = "NEBULA CERTIFICATE"
+	nebulaCertificateV2Block = "NEBULA CERTIFICATE V2"
+)
+
+// nebulaCertificate holds the parts of a Nebula certificate that are checked
+// before the certificate is handed to step-ca.
+type nebulaCertificate struct {
+	Name      string
+	IsCA      bool
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+// parseNebulaCertificates decodes every block of a PEM bundle of Nebula
+// certificates. Version 1 certificates are decoded in full; version 2
+// certificates are only checked to be well-formed.
+func parseNebulaCertificates(data string) ([]nebulaCertificate, error) {
+	var certs []nebulaCertificate
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		switch block.Type {
+		case nebulaCertificateBlock:
+			cert, err := parseNebulaCertificateV1(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("invalid Nebula certificate: %w", err)
+			}
+			certs = append(certs, cert)
+		case nebulaCertificateV2Block:
+			var raw asn1.RawValue
+			if rest, err := asn1.Unmarshal(block.Bytes, &raw); err != nil || len(rest) > 0 || raw.Tag != asn1.TagSequence {
+				return nil, fmt.Errorf("invalid Nebula v2 certificate")
+			}
+			certs = append(certs, nebulaCertificate{IsCA: true})
+		case "CERTIFICATE":
+			return nil, fmt.Errorf("found an X.509 certificate; expected a Nebula CA certificate such as the ca.crt written by `nebula-cert ca`, which starts with -----BEGIN %s-----", nebulaCertificateBlock)
+		default:
+			return nil, fmt.Errorf("unexpected PEM block %q; expected %q", block.Type, nebulaCertificateBlock)
+		}
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no Nebula certificates found in PEM data")
+	}
+	return certs, nil
+}
+
+// parseNebulaCertificateV1 decodes the protobuf encoding of a version 1
+// Nebula certificate: the details in field 1 and the signature in field 2.
+func parseNebulaCertificateV1(b []byte) (nebulaCertificate, error) {
+	var cert nebulaCertificate
+	var details, signature []byte
+	err := walkProtobuf(b, func(field int, _ uint64, data []byte) {
+		switch field {
+		case 1:
+			details = data
+		case 2:
+			signature = data
+		}
+	})
+	if err != nil {
+		return cert, err
+	}
+	if details == nil || len(signature) == 0 {
+		return cert, fmt.Errorf("missing details or signature")
+	}
+	err = walkProtobuf(details, func(field int, v uint64, data []byte) {
+		switch field {
+		case 1:
+			cert.Name = string(data)
+		case 5:
+			cert.NotBefore = time.Unix(int64(v), 0).UTC()
+		case 6:
+			cert.NotAfter = time.Unix(int64(v), 0).UTC()
+		case 8:
+			cert.IsCA = v != 0
+		}
+	})
+	return cert, err
+}
+
+// walkProtobuf calls fn for every field of a protobuf message with the
+// value of varint fields or the bytes of length-delimited fields.
+func walkProtobuf(b []byte, fn func(field int, v uint64, data []byte)) error {
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return fmt.Errorf("malformed field key")
+		}
+		b = b[n:]
+		field := int(key >> 3)
+		switch key & 7 {
+		case 0:
+			v, n := binary.Uvarint(b)
+			if n <= 0 {
+				return fmt.Errorf("malformed value of field %d", field)
+			}
+			b = b[n:]
+			fn(field, v, nil)
+		case 1:
+			if len(b) < 8 {
+				return fmt.Errorf("truncated field %d", field)
+			}
+			b = b[8:]
+		case 2:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || l > uint64(len(b)-n) {
+				return fmt.Errorf("truncated field %d", field)
+			}
+			fn(field, 0, b[n:n+int(l)])
+			b = b[n+int(l):]
+		case 5:
+			if len(b) < 4 {
+				return fmt.Errorf("truncated field %d", field)
+			}
+			b = b[4:]
+		default:
+			return fmt.Errorf("unsupported wire type in field %d", field)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package provider
+
+import (
+	"encoding/binary"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/z0link/terraform-provider-stepca/internal/client"
+)
+
+// testNebulaCertificate encodes a version 1 Nebula certificate the way
+// `nebula-cert` does, with a dummy signature.
+func testNebulaCertificate(name string, isCA bool, notAfter time.Time) string {
+	field := func(num int, wireType uint64, payload []byte) []byte {
+		b := binary.AppendUvarint(nil, uint64(num)<<3|wireType)
+		if wireType == 2 {
+			b = binary.AppendUvarint(b, uint64(len(payload)))
+		}
+		return append(b, payload...)
+	}
+	varint := func(v uint64) []byte { return binary.AppendUvarint(nil, v) }
+
+	var details []byte
+	details = append(details, field(1, 2, []byte(name))...)
+	details = append(details, field(4, 2, []byte("servers"))...)
+	details = append(details, field(5, 0, varint(uint64(notAfter.Add(-24*time.Hour).Unix())))...)
+	details = append(details, field(6, 0, varint(uint64(notAfter.Unix())))...)
+	details = append(details, field(7, 2, make([]byte, 32))...)
+	if isCA {
+		details = append(details, field(8, 0, varint(1))...)
+	}
+	details = append(details, field(100, 0, varint(0))...)
+
+	raw := append(field(1, 2, details), field(2, 2, make([]byte, 64))...)
+	return string(pem.EncodeToMemory(&pem.Block{Type: nebulaCertificateBlock, Bytes: raw}))
+}
+
+func TestParseNebulaCertificates(t *testing.T) {
+	t.Parallel()
+
+	notAfter := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	certs, err := parseNebulaCertificates(testNebulaCertificate("Example CA", true, notAfter) + testNebulaCertificate("host", false, notAfter))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(certs) != 2 || certs[0].Name != "Example CA" || !certs[0].IsCA || !certs[0].NotAfter.Equal(notAfter) || certs[1].IsCA {
+		t.Fatalf("unexpected certificates: %#v", certs)
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{name: "x509", data: testRoot, wantErr: "found an X.509 certificate"},
+		{name: "empty", data: "ca.crt", wantErr: "no Nebula certificates"},
+		{name: "truncated", data: string(pem.EncodeToMemory(&pem.Block{Type: nebulaCertificateBlock, Bytes: []byte{0x0a, 0x20, 0x01}})), wantErr: "truncated"},
+		{name: "v2 not asn1", data: string(pem.EncodeToMemory(&pem.Block{Type: nebulaCertificateV2Block, Bytes: []byte("v2")})), wantErr: "invalid Nebula v2"},
+		{name: "private key", data: string(pem.EncodeToMemory(&pem.Block{Type: "NEBULA X25519 PRIVATE KEY", Bytes: make([]byte, 32)})), wantErr: "unexpected PEM block"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := parseNebulaCertificates(tc.data); err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("expected an error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateProvisionerNebula(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	valid := testNebulaCertificate("Example CA", true, now.AddDate(1, 0, 0))
+	tests := []struct {
+		name     string
+		roots    types.String
+		wantErr  bool
+		wantWarn bool
+	}{
+		{name: "ca", roots: types.StringValue(valid)},
+		{name: "unknown", roots: types.StringUnknown()},
+		{name: "missing", roots: types.StringNull(), wantErr: true},
+		{name: "x509", roots: types.StringValue(testRoot), wantErr: true},
+		{name: "host certificate", roots: types.StringValue(testNebulaCertificate("host", false, now.AddDate(1, 0, 0))), wantErr: true},
+		{name: "expired", roots: types.StringValue(testNebulaCertificate("Old CA", true, now.AddDate(0, 0, -1))), wantWarn: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			diags := validateProvisionerNebula(provisionerNebulaModel{Roots: tc.roots}, now)
+			if diags.HasError() != tc.wantErr || (diags.WarningsCount() > 0) != tc.wantWarn {
+				t.Fatalf("expected error=%t warning=%t got %v", tc.wantErr, tc.wantWarn, diags)
+			}
+		})
+	}
+
+	if diags := validateProvisionerDetails(provisionerResourceModel{Type: types.StringValue("Nebula")}); !diags.HasError() {
+		t.Fatal("expected an error for a Nebula provisioner without a nebula block")
+	}
+}
+
+func TestProvisionerSetNebulaDetails(t *testing.T) {
+	t.Parallel()
+
+	root := testNebulaCertificate("Example CA", true, time.Now().AddDate(1, 0, 0))
+	configured := "# ca.crt\n" + root
+	data := provisionerResourceModel{Nebula: &provisionerNebulaModel{Roots: types.StringValue(configured)}}
+	sent := provisionerDetailsToClient(data)
+	if sent == nil || sent.Nebula == nil || len(sent.Nebula.Roots) != 1 || string(sent.Nebula.Roots[0]) != root {
+		t.Fatalf("unexpected admin API details: %#v", sent)
+	}
+	data.setDetails(sent)
+	if data.Nebula.Roots.ValueString() != configured {
+		t.Fatalf("equivalent roots must keep their configured form: %q", data.Nebula.Roots.ValueString())
+	}
+
+	rotated := testNebulaCertificate("Example CA 2", true, time.Now().AddDate(1, 0, 0))
+	data.setDetails(&client.ProvisionerDetails{Nebula: &client.NebulaProvisioner{Roots: [][]byte{[]byte(rotated)}}})
+	if data.Nebula.Roots.ValueString() != rotated {
+		t.Fatalf("a replaced root must show up as drift: %q", data.Nebula.Roots.ValueString())
+	}
+}
@@ -0,0 +1,433 @@
+package provider
+
+import (
+	"fmt"
+	"net/netip"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/z0link/terraform-provider-stepca/internal/client"
+)
+
+// The policy models below are shared by the policy block of a provisioner
+// and the authority policy. Blocks left out of the configuration stay absent
+// when step-ca reports no names for them.
+
+type x509PolicyModel struct {
+	AllowWildcardNames types.Bool      `tfsdk:"allow_wildcard_names"`
+	Allow              *x509NamesModel `tfsdk:"allow"`
+	Deny               *x509NamesModel `tfsdk:"deny"`
+}
+
+type x509NamesModel struct {
+	CommonNames types.List `tfsdk:"common_names"`
+	DNS         types.List `tfsdk:"dns"`
+	IPs         types.List `tfsdk:"ips"`
+	Emails      types.List `tfsdk:"emails"`
+	URIs        types.List `tfsdk:"uris"`
+}
+
+type sshPolicyModel struct {
+	User *sshUserPolicyModel `tfsdk:"user"`
+	Host *sshHostPolicyModel `tfsdk:"host"`
+}
+
+type sshUserPolicyModel struct {
+	Allow *sshUserNamesModel `tfsdk:"allow"`
+	Deny  *sshUserNamesModel `tfsdk:"deny"`
+}
+
+type sshUserNamesModel struct {
+	Emails     types.List `tfsdk:"emails"`
+	Principals types.List `tfsdk:"principals"`
+}
+
+type sshHostPolicyModel struct {
+	Allow *sshHostNamesModel `tfsdk:"allow"`
+	Deny  *sshHostNamesModel `tfsdk:"deny"`
+}
+
+type sshHostNamesModel struct {
+	DNS        types.List `tfsdk:"dns"`
+	IPs        types.List `tfsdk:"ips"`
+	Principals types.List `tfsdk:"principals"`
+}
+
+// provisionerPolicyModel is the policy block of a provisioner.
+type provisionerPolicyModel struct {
+	X509 *x509PolicyModel `tfsdk:"x509"`
+	SSH  *sshPolicyModel  `tfsdk:"ssh"`
+}
+
+func policyNameList(description string) schema.Attribute {
+	return schema.ListAttribute{Optional: true, ElementType: types.StringType, Description: description}
+}
+
+func policyX509Block() schema.Block {
+	names := func(description string) schema.Block {
+		return schema.SingleNestedBlock{
+			Description: description,
+			Attributes: map[string]schema.Attribute{
+				"common_names": policyNameList("Subject common names."),
+				"dns":          policyNameList("DNS names. A leading `*.` matches any subdomain."),
+				"ips":          policyNameList("IP addresses or CIDR ranges."),
+				"emails":       policyNameList("Email addresses, or domains such as `@example.com`."),
+				"uris":         policyNameList("URI domains, such as `*.example.com`."),
+			},
+		}
+	}
+	return schema.SingleNestedBlock{
+		Description: "Names allowed in and denied from X.509 certificates.",
+		Attributes: map[string]schema.Attribute{
+			"allow_wildcard_names": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Allow wildcard DNS names such as `*.example.com` in certificates. step-ca rejects them by default.",
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"allow": names("Names allowed in certificates. Once set, any other name is rejected."),
+			"deny":  names("Names rejected even if they are allowed."),
+		},
+	}
+}
+
+func policySSHBlock() schema.Block {
+	user := func(description string) schema.Block {
+		return schema.SingleNestedBlock{
+			Description: description,
+			Attributes: map[string]schema.Attribute{
+				"emails":     policyNameList("Email addresses, or domains such as `@example.com`."),
+				"principals": policyNameList("User principals."),
+			},
+		}
+	}
+	host := func(description string) schema.Block {
+		return schema.SingleNestedBlock{
+			Description: description,
+			Attributes: map[string]schema.Attribute{
+				"dns":        policyNameList("DNS names. A leading `*.` matches any subdomain."),
+				"ips":        policyNameList("IP addresses or CIDR ranges."),
+				"principals": policyNameList("Host principals."),
+			},
+		}
+	}
+	return schema.SingleNestedBlock{
+		Description: "Names allowed in and denied from SSH certificates.",
+		Blocks: map[string]schema.Block{
+			"user": schema.SingleNestedBlock{
+				Description: "Policy of SSH user certificates.",
+				Blocks: map[string]schema.Block{
+					"allow": user("Names allowed in user certificates. Once set, any other name is rejected."),
+					"deny":  user("Names rejected even if they are allowed."),
+				},
+			},
+			"host": schema.SingleNestedBlock{
+				Description: "Policy of SSH host certificates.",
+				Blocks: map[string]schema.Block{
+					"allow": host("Names allowed in host certificates. Once set, any other name is rejected."),
+					"deny":  host("Names rejected even if they are allowed."),
+				},
+			},
+		},
+	}
+}
+
+func provisionerPolicyBlock() schema.Block {
+	return schema.SingleNestedBlock{
+		Description: "Names the provisioner may issue certificates for, on top of the authority policy.",
+		Blocks: map[string]schema.Block{
+			"x509": policyX509Block(),
+			"ssh":  policySSHBlock(),
+		},
+	}
+}
+
+// validatePolicy checks that IP entries are addresses or CIDR ranges and
+// that DNS entries are domain names, optionally with a leading wildcard.
+func validatePolicy(base path.Path, x509 *x509PolicyModel, ssh *sshPolicyModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if x509 != nil {
+		for i, names := range []*x509NamesModel{x509.Allow, x509.Deny} {
+			if names == nil {
+				continue
+			}
+			p := base.AtName("x509").AtName([]string{"allow", "deny"}[i])
+			validatePolicyNames(&diags, p.AtName("dns"), names.DNS, validPolicyDNS, "a domain name such as example.com or *.example.com")
+			validatePolicyNames(&diags, p.AtName("ips"), names.IPs, validPolicyIP, "an IP address or CIDR range such as 10.0.0.0/8")
+		}
+	}
+	if ssh != nil && ssh.Host != nil {
+		for i, names := range []*sshHostNamesModel{ssh.Host.Allow, ssh.Host.Deny} {
+			if names == nil {
+				continue
+			}
+			p := base.AtName("ssh").AtName("host").AtName([]string{"allow", "deny"}[i])
+			validatePolicyNames(&diags, p.AtName("dns"), names.DNS, validPolicyDNS, "a domain name such as example.com or *.example.com")
+			validatePolicyNames(&diags, p.AtName("ips"), names.IPs, validPolicyIP, "an IP address or CIDR range such as 10.0.0.0/8")
+		}
+	}
+	return diags
+}
+
+func validatePolicyNames(diags *diag.Diagnostics, p path.Path, l types.List, valid func(string) bool, want string) {
+	for i, v := range l.Elements() {
+		s, ok := v.(types.String)
+		if !ok || s.IsNull() || s.IsUnknown() || valid(s.ValueString()) {
+			continue
+		}
+		diags.AddAttributeError(p.AtListIndex(i), "invalid policy", fmt.Sprintf("%q is not %s", s.ValueString(), want))
+	}
+}
+
+func validPolicyIP(v string) bool {
+	if _, err := netip.ParsePrefix(v); err == nil {
+		return true
+	}
+	_, err := netip.ParseAddr(v)
+	return err == nil
+}
+
+// validPolicyDNS accepts a domain name that may start with "*." to match its
+// subdomains. step-ca rejects any other use of "*".
+func validPolicyDNS(v string) bool {
+	domain := strings.TrimPrefix(v, "*.")
+	if domain == "" || len(domain) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// policyToClient converts the policy blocks into the admin API
+// representation, or nil when they hold no names.
+func policyToClient(x509 *x509PolicyModel, ssh *sshPolicyModel) *client.Policy {
+	var p client.Policy
+	if x509 != nil {
+		x := client.X509Policy{
+			Allow:              x509NamesToClient(x509.Allow),
+			Deny:               x509NamesToClient(x509.Deny),
+			AllowWildcardNames: boolFromOptional(x509.AllowWildcardNames),
+		}
+		if x != (client.X509Policy{}) {
+			p.X509 = &x
+		}
+	}
+	if ssh != nil {
+		var s client.SSHPolicy
+		if ssh.User != nil {
+			u := client.SSHUserPolicy{Allow: sshUserNamesToClient(ssh.User.Allow), Deny: sshUserNamesToClient(ssh.User.Deny)}
+			if u != (client.SSHUserPolicy{}) {
+				s.User = &u
+			}
+		}
+		if ssh.Host != nil {
+			h := client.SSHHostPolicy{Allow: sshHostNamesToClient(ssh.Host.Allow), Deny: sshHostNamesToClient(ssh.Host.Deny)}
+			if h != (client.SSHHostPolicy{}) {
+				s.Host = &h
+			}
+		}
+		if s != (client.SSHPolicy{}) {
+			p.SSH = &s
+		}
+	}
+	if p == (client.Policy{}) {
+		return nil
+	}
+	return &p
+}
+
+func x509NamesToClient(m *x509NamesModel) *client.X509Names {
+	if m == nil {
+		return nil
+	}
+	names := client.X509Names{
+		CommonNames: listStrings(m.CommonNames),
+		DNS:         listStrings(m.DNS),
+		IPs:         listStrings(m.IPs),
+		Emails:      listStrings(m.Emails),
+		URIs:        listStrings(m.URIs),
+	}
+	if reflect.DeepEqual(names, client.X509Names{}) {
+		return nil
+	}
+	return &names
+}
+
+func sshUserNamesToClient(m *sshUserNamesModel) *client.SSHUserNames {
+	if m == nil {
+		return nil
+	}
+	names := client.SSHUserNames{Emails: listStrings(m.Emails), Principals: listStrings(m.Principals)}
+	if reflect.DeepEqual(names, client.SSHUserNames{}) {
+		return nil
+	}
+	return &names
+}
+
+func sshHostNamesToClient(m *sshHostNamesModel) *client.SSHHostNames {
+	if m == nil {
+		return nil
+	}
+	names := client.SSHHostNames{DNS: listStrings(m.DNS), IPs: listStrings(m.IPs), Principals: listStrings(m.Principals)}
+	if reflect.DeepEqual(names, client.SSHHostNames{}) {
+		return nil
+	}
+	return &names
+}
+
+// x509PolicyFromClient refreshes the x509 policy block from step-ca.
+func x509PolicyFromClient(stored *x509PolicyModel, p *client.X509Policy) *x509PolicyModel {
+	if p == nil {
+		p = &client.X509Policy{}
+	}
+	present := stored != nil
+	if stored == nil {
+		stored = &x509PolicyModel{}
+	}
+	m := &x509PolicyModel{
+		AllowWildcardNames: boolValueOrNull(stored.AllowWildcardNames, p.AllowWildcardNames),
+		Allow:              x509NamesFromClient(stored.Allow, p.Allow),
+		Deny:               x509NamesFromClient(stored.Deny, p.Deny),
+	}
+	if !present && m.Allow == nil && m.Deny == nil && m.AllowWildcardNames.IsNull() {
+		return nil
+	}
+	return m
+}
+
+func x509NamesFromClient(stored *x509NamesModel, names *client.X509Names) *x509NamesModel {
+	if names == nil {
+		names = &client.X509Names{}
+	}
+	if stored == nil && reflect.DeepEqual(*names, client.X509Names{}) {
+		return nil
+	}
+	if stored == nil {
+		stored = &x509NamesModel{}
+	}
+	return &x509NamesModel{
+		CommonNames: stringListValue(stored.CommonNames, names.CommonNames),
+		DNS:         stringListValue(stored.DNS, names.DNS),
+		IPs:         stringListValue(stored.IPs, names.IPs),
+		Emails:      stringListValue(stored.Emails, names.Emails),
+		URIs:        stringListValue(stored.URIs, names.URIs),
+	}
+}
+
+// sshPolicyFromClient refreshes the ssh policy block from step-ca.
+func sshPolicyFromClient(stored *sshPolicyModel, p *client.SSHPolicy) *sshPolicyModel {
+	if p == nil {
+		p = &client.SSHPolicy{}
+	}
+	present := stored != nil
+	if stored == nil {
+		stored = &sshPolicyModel{}
+	}
+	m := &sshPolicyModel{}
+	user, host := p.User, p.Host
+	if user == nil {
+		user = &client.SSHUserPolicy{}
+	}
+	if host == nil {
+		host = &client.SSHHostPolicy{}
+	}
+	storedUser, storedHost := stored.User, stored.Host
+	if storedUser == nil {
+		storedUser = &sshUserPolicyModel{}
+	}
+	if storedHost == nil {
+		storedHost = &sshHostPolicyModel{}
+	}
+	m.User = &sshUserPolicyModel{
+		Allow: sshUserNamesFromClient(storedUser.Allow, user.Allow),
+		Deny:  sshUserNamesFromClient(storedUser.Deny, user.Deny),
+	}
+	if stored.User == nil && m.User.Allow == nil && m.User.Deny == nil {
+		m.User = nil
+	}
+	m.Host = &sshHostPolicyModel{
+		Allow: sshHostNamesFromClient(storedHost.Allow, host.Allow),
+		Deny:  sshHostNamesFromClient(storedHost.Deny, host.Deny),
+	}
+	if stored.Host == nil && m.Host.Allow == nil && m.Host.Deny == nil {
+		m.Host = nil
+	}
+	if !present && m.User == nil && m.Host == nil {
+		return nil
+	}
+	return m
+}
+
+func sshUserNamesFromClient(stored *sshUserNamesModel, names *client.SSHUserNames) *sshUserNamesModel {
+	if names == nil {
+		names = &client.SSHUserNames{}
+	}
+	if stored == nil && reflect.DeepEqual(*names, client.SSHUserNames{}) {
+		return nil
+	}
+	if stored == nil {
+		stored = &sshUserNamesModel{}
+	}
+	return &sshUserNamesModel{
+		Emails:     stringListValue(stored.Emails, names.Emails),
+		Principals: stringListValue(stored.Principals, names.Principals),
+	}
+}
+
+func sshHostNamesFromClient(stored *sshHostNamesModel, names *client.SSHHostNames) *sshHostNamesModel {
+	if names == nil {
+		names = &client.SSHHostNames{}
+	}
+	if stored == nil && reflect.DeepEqual(*names, client.SSHHostNames{}) {
+		return nil
+	}
+	if stored == nil {
+		stored = &sshHostNamesModel{}
+	}
+	return &sshHostNamesModel{
+		DNS:        stringListValue(stored.DNS, names.DNS),
+		IPs:        stringListValue(stored.IPs, names.IPs),
+		Principals: stringListValue(stored.Principals, names.Principals),
+	}
+}
+
+// setPolicy refreshes the policy block of a provisioner from step-ca. A
+// policy without names keeps an absent block absent.
+func (m *provisionerResourceModel) setPolicy(p *client.Policy) {
+	if p == nil {
+		p = &client.Policy{}
+	}
+	stored := m.Policy
+	if stored == nil {
+		stored = &provisionerPolicyModel{}
+	}
+	policy := &provisionerPolicyModel{
+		X509: x509PolicyFromClient(stored.X509, p.X509),
+		SSH:  sshPolicyFromClient(stored.SSH, p.SSH),
+	}
+	if m.Policy == nil && policy.X509 == nil && policy.SSH == nil {
+		return
+	}
+	m.Policy = policy
+}
+
+func provisionerPolicyToClient(m *provisionerPolicyModel) *client.Policy {
+	if m == nil {
+		return nil
+	}
+	return policyToClient(m.X509, m.SSH)
+}
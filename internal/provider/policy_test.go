@@ -0,0 +1,118 @@
+package provider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/z0link/terraform-provider-stepca/internal/client"
+)
+
+func policyList(values ...string) types.List {
+	return stringListValue(types.ListNull(types.StringType), values)
+}
+
+func TestValidatePolicy(t *testing.T) {
+	t.Parallel()
+
+	x509 := func(dns, ips []string) *x509PolicyModel {
+		return &x509PolicyModel{Allow: &x509NamesModel{DNS: policyList(dns...), IPs: policyList(ips...)}}
+	}
+	tests := []struct {
+		name     string
+		x509     *x509PolicyModel
+		ssh      *sshPolicyModel
+		wantErrs int
+	}{
+		{name: "valid", x509: x509([]string{"example.com", "*.example.com", "_acme.internal"}, []string{"10.0.0.0/8", "192.168.1.1", "2001:db8::/32"})},
+		{name: "empty", x509: &x509PolicyModel{}, ssh: &sshPolicyModel{Host: &sshHostPolicyModel{}}},
+		{name: "bad ips", x509: x509(nil, []string{"10.0.0.0/33", "example.com"}), wantErrs: 2},
+		{name: "bad dns", x509: x509([]string{"ex ample.com", "a.*.example.com", "*", ".example.com", "-a.example.com"}, nil), wantErrs: 5},
+		{
+			name:     "ssh host deny",
+			ssh:      &sshPolicyModel{Host: &sshHostPolicyModel{Deny: &sshHostNamesModel{DNS: policyList("bad..example.com"), IPs: policyList("10.0.0.1/8")}}},
+			wantErrs: 1,
+		},
+		{
+			name: "unknown entries",
+			x509: &x509PolicyModel{Allow: &x509NamesModel{
+				DNS: types.ListValueMust(types.StringType, []attr.Value{types.StringUnknown()}),
+				IPs: types.ListUnknown(types.StringType),
+			}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if diags := validatePolicy(path.Root("policy"), tc.x509, tc.ssh); diags.ErrorsCount() != tc.wantErrs {
+				t.Fatalf("expected %d errors, got %v", tc.wantErrs, diags)
+			}
+		})
+	}
+}
+
+func TestProvisionerSetPolicy(t *testing.T) {
+	t.Parallel()
+
+	var data provisionerResourceModel
+	data.setPolicy(&client.Policy{X509: &client.X509Policy{Allow: &client.X509Names{}}})
+	if data.Policy != nil {
+		t.Fatalf("expected no policy block for an empty policy, got %#v", data.Policy)
+	}
+
+	data.Policy = &provisionerPolicyModel{
+		X509: &x509PolicyModel{
+			AllowWildcardNames: types.BoolValue(true),
+			Allow:              &x509NamesModel{DNS: policyList("*.example.com"), IPs: policyList("10.0.0.0/8"), Emails: types.ListValueMust(types.StringType, nil)},
+		},
+		SSH: &sshPolicyModel{User: &sshUserPolicyModel{Deny: &sshUserNamesModel{Principals: policyList("root")}}},
+	}
+	sent := provisionerPolicyToClient(data.Policy)
+	want := &client.Policy{
+		X509: &client.X509Policy{Allow: &client.X509Names{DNS: []string{"*.example.com"}, IPs: []string{"10.0.0.0/8"}}, AllowWildcardNames: true},
+		SSH:  &client.SSHPolicy{User: &client.SSHUserPolicy{Deny: &client.SSHUserNames{Principals: []string{"root"}}}},
+	}
+	if !reflect.DeepEqual(sent, want) {
+		t.Fatalf("unexpected admin API policy: %#v", sent)
+	}
+
+	data.setPolicy(sent)
+	if got := provisionerPolicyToClient(data.Policy); !reflect.DeepEqual(got, want) {
+		t.Fatalf("policy did not round trip: %#v", got)
+	}
+	if allow := data.Policy.X509.Allow; allow.Emails.IsNull() || !allow.CommonNames.IsNull() || data.Policy.SSH.Host != nil {
+		t.Fatalf("configured empty lists and absent blocks must be kept: %#v", data.Policy)
+	}
+
+	data.setPolicy(&client.Policy{X509: &client.X509Policy{Allow: &client.X509Names{DNS: []string{"*.example.org"}}}})
+	x := data.Policy.X509
+	if x.AllowWildcardNames.ValueBool() || x.Allow.DNS.Elements()[0] != types.StringValue("*.example.org") || !x.Allow.IPs.IsNull() {
+		t.Fatalf("changes in step-ca must show up as drift: %#v", x)
+	}
+	if data.Policy.SSH == nil || data.Policy.SSH.User.Deny == nil || !data.Policy.SSH.User.Deny.Principals.IsNull() {
+		t.Fatalf("a removed ssh policy must show up as drift: %#v", data.Policy.SSH)
+	}
+}
+
+func TestProvisionerResourceUpdatePolicy(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeProvisionerClient{getResp: &client.Provisioner{Name: "acme", Type: "ACME"}}
+	r := &provisionerResource{client: fake}
+	state := provisionerResourceModel{Name: types.StringValue("acme"), Type: types.StringValue("ACME")}
+	plan := state
+	plan.Policy = &provisionerPolicyModel{X509: &x509PolicyModel{Deny: &x509NamesModel{DNS: policyList("admin.example.com")}}}
+	updated, diags := r.updateProvisioner(context.Background(), &state, &plan)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %#v", diags)
+	}
+	if !fake.replaceCalled || fake.replaceInput.Policy == nil || fake.replaceInput.Policy.X509.Deny.DNS[0] != "admin.example.com" {
+		t.Fatalf("unexpected replace payload: %#v", fake.replaceInput.Policy)
+	}
+	if updated == nil || updated.Policy != plan.Policy {
+		t.Fatalf("unexpected updated state: %#v", updated)
+	}
+}
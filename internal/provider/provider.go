@@ -2,9 +2,16 @@ package provider
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
@@ -15,7 +22,10 @@ import (
 )
 
 // Ensure implementation satisfies interfaces
-var _ provider.Provider = &stepcaProvider{}
+var (
+	_ provider.Provider                       = &stepcaProvider{}
+	_ provider.ProviderWithEphemeralResources = &stepcaProvider{}
+)
 
 // New creates a new provider
 func New() provider.Provider {
@@ -28,9 +38,21 @@ type stepcaProviderModel struct {
 	CAURL            types.String `tfsdk:"ca_url"`
 	AdminName        types.String `tfsdk:"admin_name"`
 	AdminKey         types.String `tfsdk:"admin_key"`
+	AdminCertificate types.String `tfsdk:"admin_certificate"`
 	AdminProvisioner types.String `tfsdk:"admin_provisioner"`
 	Token            types.String `tfsdk:"token"`
 	AdminToken       types.String `tfsdk:"admin_token"`
+
+	ProvisionerName     types.String `tfsdk:"provisioner_name"`
+	ProvisionerKey      types.String `tfsdk:"provisioner_key"`
+	ProvisionerPassword types.String `tfsdk:"provisioner_password"`
+
+	RootFingerprint types.String `tfsdk:"root_fingerprint"`
+	RootCAFile      types.String `tfsdk:"root_ca_file"`
+	RootCAPEM       types.String `tfsdk:"root_ca_pem"`
+
+	RetryMaxAttempts types.Int64  `tfsdk:"retry_max_attempts"`
+	RetryMaxDuration types.String `tfsdk:"retry_max_duration"`
 }
 
 func (p *stepcaProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
@@ -41,14 +63,32 @@ func (p *stepcaProvider) Metadata(ctx context.Context, req provider.MetadataRequ
 func (p *stepcaProvider) Schema(ctx context.Context, req provider.SchemaRequest, resp *provider.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Attributes: map[string]schema.Attribute{
-			"ca_url":            schema.StringAttribute{Required: true},
-			"admin_name":        schema.StringAttribute{Optional: true},
-			"admin_key":         schema.StringAttribute{Optional: true},
+			"ca_url":     schema.StringAttribute{Required: true},
+			"admin_name": schema.StringAttribute{Optional: true},
+			"admin_key":  schema.StringAttribute{Optional: true, Sensitive: true},
+			// Certificate chain of admin_key issued by admin_provisioner;
+			// step-ca only accepts admin tokens carrying it in x5c.
+			"admin_certificate": schema.StringAttribute{Optional: true},
 			"admin_provisioner": schema.StringAttribute{Optional: true},
-			"token":             schema.StringAttribute{Required: true, Sensitive: true},
-			// Token for admin API calls. Generate with the admin key if
-			// using a JWK admin provisioner.
+			// Static one-time token. Only usable for a single signing
+			// request; prefer the provisioner_* settings below.
+			"token": schema.StringAttribute{Optional: true, Sensitive: true},
+			// Token for admin API calls. When unset the client mints one
+			// per request from admin_name/admin_key/admin_certificate.
 			"admin_token": schema.StringAttribute{Optional: true, Sensitive: true},
+			// JWK provisioner used to mint a fresh one-time token for
+			// every certificate request.
+			"provisioner_name":     schema.StringAttribute{Optional: true},
+			"provisioner_key":      schema.StringAttribute{Optional: true, Sensitive: true},
+			"provisioner_password": schema.StringAttribute{Optional: true, Sensitive: true},
+			// Trust bootstrap, equivalent to `step ca bootstrap`.
+			"root_fingerprint": schema.StringAttribute{Optional: true},
+			"root_ca_file":     schema.StringAttribute{Optional: true},
+			"root_ca_pem":      schema.StringAttribute{Optional: true},
+			// Retry policy for transient CA failures such as 502/503
+			// responses while step-ca reloads.
+			"retry_max_attempts": schema.Int64Attribute{Optional: true},
+			"retry_max_duration": schema.StringAttribute{Optional: true},
 		},
 	}
 }
@@ -63,25 +103,54 @@ func (p *stepcaProvider) Configure(ctx context.Context, req provider.ConfigureRe
 
 	credDiags := validateAdminCredentials(&data)
 	resp.Diagnostics.Append(credDiags...)
+	resp.Diagnostics.Append(validateSignCredentials(&data)...)
+	resp.Diagnostics.Append(validateRootTrust(&data)...)
+	attempts, budget, retryDiags := retrySettings(&data)
+	resp.Diagnostics.Append(retryDiags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	c := client.New(data.CAURL.ValueString(), data.Token.ValueString())
+	roots, rootDiags := loadRootCertificates(&data)
+	resp.Diagnostics.Append(rootDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	c := client.New(data.CAURL.ValueString(), data.Token.ValueString()).WithRetry(attempts, budget)
 	if !data.AdminName.IsNull() && !data.AdminName.IsUnknown() {
 		c = c.WithAdminName(data.AdminName.ValueString())
 	}
 	if !data.AdminKey.IsNull() && !data.AdminKey.IsUnknown() {
 		c = c.WithAdminKey(data.AdminKey.ValueString())
 	}
+	if !data.AdminCertificate.IsNull() && !data.AdminCertificate.IsUnknown() {
+		c = c.WithAdminCertificate(data.AdminCertificate.ValueString())
+	}
 	if !data.AdminProvisioner.IsNull() && !data.AdminProvisioner.IsUnknown() {
 		c = c.WithAdminProvisioner(data.AdminProvisioner.ValueString())
 	}
 	if !data.AdminToken.IsNull() && !data.AdminToken.IsUnknown() {
 		c = c.WithAdminToken(data.AdminToken.ValueString())
 	}
+	if !data.ProvisionerName.IsNull() && !data.ProvisionerName.IsUnknown() {
+		c = c.WithProvisioner(data.ProvisionerName.ValueString())
+	}
+	if !data.ProvisionerKey.IsNull() && !data.ProvisionerKey.IsUnknown() {
+		c = c.WithProvisionerKey(data.ProvisionerKey.ValueString())
+	}
+	if !data.ProvisionerPassword.IsNull() && !data.ProvisionerPassword.IsUnknown() {
+		c = c.WithProvisionerPassword(data.ProvisionerPassword.ValueString())
+	}
+	if len(roots) > 0 {
+		c = c.WithRootCertificates(roots)
+	}
+	if !data.RootFingerprint.IsNull() && !data.RootFingerprint.IsUnknown() {
+		c = c.WithRootFingerprint(data.RootFingerprint.ValueString())
+	}
 	resp.DataSourceData = c
 	resp.ResourceData = c
+	resp.EphemeralResourceData = c
 }
 
 func validateAdminCredentials(data *stepcaProviderModel) diag.Diagnostics {
@@ -102,16 +171,137 @@ func validateAdminCredentials(data *stepcaProviderModel) diag.Diagnostics {
 			"missing admin credentials",
 			"configure either admin_token for admin API access or both admin_name and admin_key so the provider can mint one",
 		)
+		return diags
+	}
+	// admin_token is used as-is when set, so the key pair is only a fallback
+	// and does not need the rest of the minting configuration.
+	if !adminTokenSet && adminNameSet && adminKeySet {
+		if data.AdminCertificate.IsNull() {
+			diags.AddError(
+				"incomplete admin key configuration",
+				"admin_certificate must be set with admin_name and admin_key unless admin_token is set; step-ca only accepts admin tokens carrying the admin's certificate",
+			)
+		}
+		if data.AdminProvisioner.IsNull() {
+			diags.AddError(
+				"incomplete admin key configuration",
+				"admin_provisioner must be set with admin_name and admin_key unless admin_token is set; it is the issuer of minted admin tokens",
+			)
+		}
+	}
+	return diags
+}
+
+func validateSignCredentials(data *stepcaProviderModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	nameSet := !data.ProvisionerName.IsNull() && !data.ProvisionerName.IsUnknown()
+	keySet := !data.ProvisionerKey.IsNull() && !data.ProvisionerKey.IsUnknown()
+	passwordSet := !data.ProvisionerPassword.IsNull() && !data.ProvisionerPassword.IsUnknown()
+
+	if nameSet && !keySet && !passwordSet {
+		diags.AddError(
+			"incomplete provisioner configuration",
+			"provisioner_name requires provisioner_key or provisioner_password so the provider can mint one-time tokens",
+		)
+	}
+	if !nameSet && (keySet || passwordSet) {
+		diags.AddError(
+			"incomplete provisioner configuration",
+			"provisioner_key and provisioner_password require provisioner_name",
+		)
 	}
 	return diags
 }
 
+func validateRootTrust(data *stepcaProviderModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	fileSet := !data.RootCAFile.IsNull() && !data.RootCAFile.IsUnknown()
+	pemSet := !data.RootCAPEM.IsNull() && !data.RootCAPEM.IsUnknown()
+
+	if fileSet && pemSet {
+		diags.AddError(
+			"conflicting root CA configuration",
+			"set only one of root_ca_file and root_ca_pem",
+		)
+	}
+	if !data.RootFingerprint.IsNull() && !data.RootFingerprint.IsUnknown() {
+		fp := client.NormalizeFingerprint(data.RootFingerprint.ValueString())
+		if _, err := hex.DecodeString(fp); err != nil || len(fp) != sha256.Size*2 {
+			diags.AddError(
+				"invalid root fingerprint",
+				"root_fingerprint must be the hex-encoded SHA-256 fingerprint of the root certificate, as printed by `step certificate fingerprint`",
+			)
+		}
+	}
+	return diags
+}
+
+const (
+	defaultRetryMaxAttempts = 3
+	defaultRetryMaxDuration = time.Minute
+)
+
+// retrySettings returns the configured retry policy, falling back to defaults.
+func retrySettings(data *stepcaProviderModel) (int, time.Duration, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	attempts := defaultRetryMaxAttempts
+	budget := defaultRetryMaxDuration
+
+	if !data.RetryMaxAttempts.IsNull() && !data.RetryMaxAttempts.IsUnknown() {
+		attempts = int(data.RetryMaxAttempts.ValueInt64())
+		if attempts < 1 {
+			diags.AddError("invalid retry configuration", "retry_max_attempts must be at least 1; use 1 to disable retries")
+		}
+	}
+	if !data.RetryMaxDuration.IsNull() && !data.RetryMaxDuration.IsUnknown() {
+		d, err := time.ParseDuration(data.RetryMaxDuration.ValueString())
+		if err != nil || d <= 0 {
+			diags.AddError("invalid retry configuration", fmt.Sprintf("retry_max_duration must be a positive duration such as \"90s\" or \"5m\", got %q", data.RetryMaxDuration.ValueString()))
+		}
+		budget = d
+	}
+	return attempts, budget, diags
+}
+
+func loadRootCertificates(data *stepcaProviderModel) ([]*x509.Certificate, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	var bundle string
+	switch {
+	case !data.RootCAPEM.IsNull() && !data.RootCAPEM.IsUnknown():
+		bundle = data.RootCAPEM.ValueString()
+	case !data.RootCAFile.IsNull() && !data.RootCAFile.IsUnknown():
+		b, err := os.ReadFile(data.RootCAFile.ValueString())
+		if err != nil {
+			diags.AddError("failed to read root_ca_file", err.Error())
+			return nil, diags
+		}
+		bundle = string(b)
+	default:
+		return nil, diags
+	}
+	certs, err := parseCertificates(bundle)
+	if err != nil {
+		diags.AddError("invalid root certificate", err.Error())
+		return nil, diags
+	}
+	return certs, diags
+}
+
 func (p *stepcaProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewCertificateResource,
 		NewProvisionerResource,
 		NewAdminResource,
 		NewTemplateResource,
+		NewAuthorityPolicyResource,
+		NewPrivateKeyResource,
+		NewCSRResource,
+	}
+}
+
+func (p *stepcaProvider) EphemeralResources(ctx context.Context) []func() ephemeral.EphemeralResource {
+	return []func() ephemeral.EphemeralResource{
+		NewEphemeralCertificateResource,
 	}
 }
 
@@ -120,6 +310,7 @@ func (p *stepcaProvider) DataSources(ctx context.Context) []func() datasource.Da
 		NewVersionDataSource,
 		NewCACertificateDataSource,
 		NewProvisionersDataSource,
-    NewTemplateDataSource,
+		NewTemplateDataSource,
+		NewAuthorityPolicyDataSource,
 	}
 }
@@ -1,7 +1,11 @@
 package provider
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
@@ -24,17 +28,53 @@ func TestValidateAdminCredentials(t *testing.T) {
 		{
 			name: "admin key pair",
 			model: stepcaProviderModel{
-				AdminName: types.StringValue("admin@example.com"),
-				AdminKey:  types.StringValue("/path/to/key"),
+				AdminName:        types.StringValue("admin@example.com"),
+				AdminKey:         types.StringValue("/path/to/key"),
+				AdminCertificate: types.StringValue("/path/to/crt"),
+				AdminProvisioner: types.StringValue("admin"),
 			},
 		},
 		{
 			name: "token and key pair",
 			model: stepcaProviderModel{
-				AdminToken: types.StringValue("token"),
-				AdminName:  types.StringValue("admin@example.com"),
-				AdminKey:   types.StringValue("/path/to/key"),
+				AdminToken:       types.StringValue("token"),
+				AdminName:        types.StringValue("admin@example.com"),
+				AdminKey:         types.StringValue("/path/to/key"),
+				AdminCertificate: types.StringValue("/path/to/crt"),
+				AdminProvisioner: types.StringValue("admin"),
+			},
+		},
+		{
+			name: "token and key pair without certificate",
+			model: stepcaProviderModel{
+				AdminToken:       types.StringValue("token"),
+				AdminName:        types.StringValue("admin@example.com"),
+				AdminKey:         types.StringValue("/path/to/key"),
+				AdminCertificate: types.StringNull(),
+				AdminProvisioner: types.StringNull(),
+			},
+		},
+		{
+			name: "key pair without provisioner",
+			model: stepcaProviderModel{
+				AdminName:        types.StringValue("admin@example.com"),
+				AdminKey:         types.StringValue("/path/to/key"),
+				AdminCertificate: types.StringValue("/path/to/crt"),
+				AdminProvisioner: types.StringNull(),
+			},
+			wantErr: true,
+			summary: "incomplete admin key configuration",
+		},
+		{
+			name: "key pair without certificate",
+			model: stepcaProviderModel{
+				AdminName:        types.StringValue("admin@example.com"),
+				AdminKey:         types.StringValue("/path/to/key"),
+				AdminCertificate: types.StringNull(),
+				AdminProvisioner: types.StringValue("admin"),
 			},
+			wantErr: true,
+			summary: "incomplete admin key configuration",
 		},
 		{
 			name:    "missing all",
@@ -77,3 +117,153 @@ func TestValidateAdminCredentials(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateSignCredentials(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		model   stepcaProviderModel
+		wantErr bool
+	}{
+		{
+			name:  "static token",
+			model: stepcaProviderModel{Token: types.StringValue("ott")},
+		},
+		{
+			name:  "nothing configured",
+			model: stepcaProviderModel{},
+		},
+		{
+			name: "provisioner key",
+			model: stepcaProviderModel{
+				ProvisionerName: types.StringValue("ci"),
+				ProvisionerKey:  types.StringValue("/path/to/key.json"),
+			},
+		},
+		{
+			name: "provisioner password",
+			model: stepcaProviderModel{
+				ProvisionerName:     types.StringValue("ci"),
+				ProvisionerPassword: types.StringValue("secret"),
+			},
+		},
+		{
+			name:    "name without key material",
+			model:   stepcaProviderModel{ProvisionerName: types.StringValue("ci")},
+			wantErr: true,
+		},
+		{
+			name:    "password without name",
+			model:   stepcaProviderModel{ProvisionerPassword: types.StringValue("secret")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags := validateSignCredentials(&tt.model)
+			if diags.HasError() != tt.wantErr {
+				t.Fatalf("unexpected error state: %v", diags)
+			}
+			if tt.wantErr && diags[0].Summary() != "incomplete provisioner configuration" {
+				t.Fatalf("unexpected summary: %s", diags[0].Summary())
+			}
+		})
+	}
+}
+
+func TestValidateRootTrust(t *testing.T) {
+	t.Parallel()
+
+	fp := strings.Repeat("ab", 32)
+	tests := []struct {
+		name    string
+		model   stepcaProviderModel
+		summary string
+	}{
+		{name: "nothing configured"},
+		{name: "plain fingerprint", model: stepcaProviderModel{RootFingerprint: types.StringValue(fp)}},
+		{
+			name:  "colon separated fingerprint",
+			model: stepcaProviderModel{RootFingerprint: types.StringValue(strings.ToUpper(strings.Repeat("ab:", 31) + "ab"))},
+		},
+		{
+			name:    "short fingerprint",
+			model:   stepcaProviderModel{RootFingerprint: types.StringValue("abcd")},
+			summary: "invalid root fingerprint",
+		},
+		{
+			name: "file and pem",
+			model: stepcaProviderModel{
+				RootCAFile: types.StringValue("/etc/step/root_ca.crt"),
+				RootCAPEM:  types.StringValue("-----BEGIN CERTIFICATE-----"),
+			},
+			summary: "conflicting root CA configuration",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags := validateRootTrust(&tt.model)
+			if diags.HasError() != (tt.summary != "") {
+				t.Fatalf("unexpected error state: %v", diags)
+			}
+			if tt.summary != "" && diags[0].Summary() != tt.summary {
+				t.Fatalf("unexpected summary: %s", diags[0].Summary())
+			}
+		})
+	}
+}
+
+func TestLoadRootCertificates(t *testing.T) {
+	t.Parallel()
+
+	bundle := testCertificate(t, 1, "root.test") + testCertificate(t, 2, "other.test")
+	path := filepath.Join(t.TempDir(), "roots.pem")
+	if err := os.WriteFile(path, []byte(bundle), 0o600); err != nil {
+		t.Fatalf("write bundle: %v", err)
+	}
+
+	certs, diags := loadRootCertificates(&stepcaProviderModel{RootCAFile: types.StringValue(path)})
+	if diags.HasError() || len(certs) != 2 {
+		t.Fatalf("unexpected result: %d certs, %v", len(certs), diags)
+	}
+
+	certs, diags = loadRootCertificates(&stepcaProviderModel{RootCAPEM: types.StringValue(bundle)})
+	if diags.HasError() || len(certs) != 2 {
+		t.Fatalf("unexpected result: %d certs, %v", len(certs), diags)
+	}
+
+	_, diags = loadRootCertificates(&stepcaProviderModel{RootCAPEM: types.StringValue("not a certificate")})
+	if !diags.HasError() {
+		t.Fatal("expected error for invalid PEM")
+	}
+}
+
+func TestRetrySettings(t *testing.T) {
+	t.Parallel()
+
+	attempts, budget, diags := retrySettings(&stepcaProviderModel{})
+	if diags.HasError() || attempts != defaultRetryMaxAttempts || budget != defaultRetryMaxDuration {
+		t.Fatalf("unexpected defaults: %d %s %v", attempts, budget, diags)
+	}
+
+	attempts, budget, diags = retrySettings(&stepcaProviderModel{
+		RetryMaxAttempts: types.Int64Value(6),
+		RetryMaxDuration: types.StringValue("5m"),
+	})
+	if diags.HasError() || attempts != 6 || budget != 5*time.Minute {
+		t.Fatalf("unexpected settings: %d %s %v", attempts, budget, diags)
+	}
+
+	for _, model := range []stepcaProviderModel{
+		{RetryMaxAttempts: types.Int64Value(0)},
+		{RetryMaxDuration: types.StringValue("soon")},
+		{RetryMaxDuration: types.StringValue("-1s")},
+	} {
+		if _, _, diags := retrySettings(&model); !diags.HasError() {
+			t.Fatalf("expected error for %#v", model)
+		}
+	}
+}
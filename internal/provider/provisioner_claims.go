@@ -0,0 +1,202 @@
+package provider
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/z0link/terraform-provider-stepca/internal/client"
+)
+
+// provisionerClaimsModel holds the certificate lifetimes and flags of a
+// provisioner. Attribute names follow the claims of step-ca's ca.json.
+type provisionerClaimsModel struct {
+	MinTLSCertDuration         types.String `tfsdk:"min_tls_cert_duration"`
+	MaxTLSCertDuration         types.String `tfsdk:"max_tls_cert_duration"`
+	DefaultTLSCertDuration     types.String `tfsdk:"default_tls_cert_duration"`
+	MinUserSSHCertDuration     types.String `tfsdk:"min_user_ssh_cert_duration"`
+	MaxUserSSHCertDuration     types.String `tfsdk:"max_user_ssh_cert_duration"`
+	DefaultUserSSHCertDuration types.String `tfsdk:"default_user_ssh_cert_duration"`
+	MinHostSSHCertDuration     types.String `tfsdk:"min_host_ssh_cert_duration"`
+	MaxHostSSHCertDuration     types.String `tfsdk:"max_host_ssh_cert_duration"`
+	DefaultHostSSHCertDuration types.String `tfsdk:"default_host_ssh_cert_duration"`
+	DisableRenewal             types.Bool   `tfsdk:"disable_renewal"`
+	AllowRenewalAfterExpiry    types.Bool   `tfsdk:"allow_renewal_after_expiry"`
+	EnableSSHCA                types.Bool   `tfsdk:"enable_ssh_ca"`
+	DisableSmallstepExtensions types.Bool   `tfsdk:"disable_smallstep_extensions"`
+}
+
+// durationGroup is one min, default and max triple of the claims.
+type durationGroup struct {
+	names  [3]string
+	values [3]types.String
+}
+
+func (m provisionerClaimsModel) durationGroups() []durationGroup {
+	return []durationGroup{
+		{
+			[3]string{"min_tls_cert_duration", "default_tls_cert_duration", "max_tls_cert_duration"},
+			[3]types.String{m.MinTLSCertDuration, m.DefaultTLSCertDuration, m.MaxTLSCertDuration},
+		},
+		{
+			[3]string{"min_user_ssh_cert_duration", "default_user_ssh_cert_duration", "max_user_ssh_cert_duration"},
+			[3]types.String{m.MinUserSSHCertDuration, m.DefaultUserSSHCertDuration, m.MaxUserSSHCertDuration},
+		},
+		{
+			[3]string{"min_host_ssh_cert_duration", "default_host_ssh_cert_duration", "max_host_ssh_cert_duration"},
+			[3]types.String{m.MinHostSSHCertDuration, m.DefaultHostSSHCertDuration, m.MaxHostSSHCertDuration},
+		},
+	}
+}
+
+func provisionerClaimsBlock() schema.Block {
+	duration := func(description string) schema.Attribute {
+		return schema.StringAttribute{Optional: true, Description: description + " as a duration, such as `24h`."}
+	}
+	flag := func(description string) schema.Attribute {
+		return schema.BoolAttribute{Optional: true, Description: description}
+	}
+	return schema.SingleNestedBlock{
+		Description: "Certificate lifetimes and flags of the provisioner. Unset claims use the authority's defaults.",
+		Attributes: map[string]schema.Attribute{
+			"min_tls_cert_duration":          duration("Minimum lifetime of X.509 certificates"),
+			"max_tls_cert_duration":          duration("Maximum lifetime of X.509 certificates"),
+			"default_tls_cert_duration":      duration("Default lifetime of X.509 certificates"),
+			"min_user_ssh_cert_duration":     duration("Minimum lifetime of SSH user certificates"),
+			"max_user_ssh_cert_duration":     duration("Maximum lifetime of SSH user certificates"),
+			"default_user_ssh_cert_duration": duration("Default lifetime of SSH user certificates"),
+			"min_host_ssh_cert_duration":     duration("Minimum lifetime of SSH host certificates"),
+			"max_host_ssh_cert_duration":     duration("Maximum lifetime of SSH host certificates"),
+			"default_host_ssh_cert_duration": duration("Default lifetime of SSH host certificates"),
+			"disable_renewal":                flag("Reject renewal of certificates issued by the provisioner."),
+			"allow_renewal_after_expiry":     flag("Allow renewal of expired certificates."),
+			"enable_ssh_ca":                  flag("Allow the provisioner to issue SSH certificates."),
+			"disable_smallstep_extensions":   flag("Leave the step provisioner extension out of issued certificates."),
+		},
+	}
+}
+
+// validateProvisionerClaims checks the duration syntax and that every
+// configured min <= default <= max.
+func validateProvisionerClaims(m provisionerClaimsModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	block := path.Root("claims")
+	for _, g := range m.durationGroups() {
+		var parsed [3]time.Duration
+		for i, value := range g.values {
+			v, ok := optionalStringValue(value)
+			if !ok {
+				continue
+			}
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				diags.AddAttributeError(block.AtName(g.names[i]), "invalid provisioner configuration",
+					fmt.Sprintf("%s must be a positive duration such as 24h, got %q", g.names[i], v))
+				continue
+			}
+			parsed[i] = d
+		}
+		for i := range parsed {
+			for j := i + 1; j < len(parsed); j++ {
+				if parsed[i] > 0 && parsed[j] > 0 && parsed[i] > parsed[j] {
+					diags.AddAttributeError(block.AtName(g.names[j]), "invalid provisioner configuration",
+						fmt.Sprintf("%s (%s) must not be less than %s (%s)", g.names[j], parsed[j], g.names[i], parsed[i]))
+				}
+			}
+		}
+	}
+	return diags
+}
+
+// provisionerClaimsToClient converts the claims block into the admin API
+// representation.
+func provisionerClaimsToClient(m *provisionerClaimsModel) *client.ProvisionerClaims {
+	if m == nil {
+		return nil
+	}
+	durations := func(lo, def, hi types.String) *client.ProvisionerDurations {
+		d := client.ProvisionerDurations{Min: lo.ValueString(), Default: def.ValueString(), Max: hi.ValueString()}
+		if d == (client.ProvisionerDurations{}) {
+			return nil
+		}
+		return &d
+	}
+	claims := &client.ProvisionerClaims{
+		DisableRenewal:             boolFromOptional(m.DisableRenewal),
+		AllowRenewalAfterExpiry:    boolFromOptional(m.AllowRenewalAfterExpiry),
+		DisableSmallstepExtensions: boolFromOptional(m.DisableSmallstepExtensions),
+	}
+	if d := durations(m.MinTLSCertDuration, m.DefaultTLSCertDuration, m.MaxTLSCertDuration); d != nil {
+		claims.X509 = &client.X509Claims{Enabled: true, Durations: d}
+	}
+	ssh := client.SSHClaims{
+		Enabled:       boolFromOptional(m.EnableSSHCA),
+		UserDurations: durations(m.MinUserSSHCertDuration, m.DefaultUserSSHCertDuration, m.MaxUserSSHCertDuration),
+		HostDurations: durations(m.MinHostSSHCertDuration, m.DefaultHostSSHCertDuration, m.MaxHostSSHCertDuration),
+	}
+	if !reflect.DeepEqual(ssh, client.SSHClaims{}) {
+		claims.SSH = &ssh
+	}
+	return claims
+}
+
+// setClaims refreshes the claims block from step-ca. Durations equal to the
+// stored ones keep their stored form, so "24h" is not reported as changed
+// when step-ca returns "24h0m0s". Claims without values keep an absent block
+// absent.
+func (m *provisionerResourceModel) setClaims(claims *client.ProvisionerClaims) {
+	if claims == nil {
+		claims = &client.ProvisionerClaims{}
+	}
+	var x509, user, host client.ProvisionerDurations
+	var sshEnabled bool
+	if claims.X509 != nil && claims.X509.Durations != nil {
+		x509 = *claims.X509.Durations
+	}
+	if claims.SSH != nil {
+		sshEnabled = claims.SSH.Enabled
+		if claims.SSH.UserDurations != nil {
+			user = *claims.SSH.UserDurations
+		}
+		if claims.SSH.HostDurations != nil {
+			host = *claims.SSH.HostDurations
+		}
+	}
+	empty := x509 == client.ProvisionerDurations{} && user == client.ProvisionerDurations{} &&
+		host == client.ProvisionerDurations{} && !sshEnabled && !claims.DisableRenewal &&
+		!claims.AllowRenewalAfterExpiry && !claims.DisableSmallstepExtensions
+	if empty && m.Claims == nil {
+		return
+	}
+
+	stored := m.Claims
+	if stored == nil {
+		stored = &provisionerClaimsModel{}
+	}
+	duration := func(stored types.String, v string) types.String {
+		if sameDuration(stored.ValueString(), v) {
+			return stored
+		}
+		return stringValueOrNull(v)
+	}
+	m.Claims = &provisionerClaimsModel{
+		MinTLSCertDuration:         duration(stored.MinTLSCertDuration, x509.Min),
+		MaxTLSCertDuration:         duration(stored.MaxTLSCertDuration, x509.Max),
+		DefaultTLSCertDuration:     duration(stored.DefaultTLSCertDuration, x509.Default),
+		MinUserSSHCertDuration:     duration(stored.MinUserSSHCertDuration, user.Min),
+		MaxUserSSHCertDuration:     duration(stored.MaxUserSSHCertDuration, user.Max),
+		DefaultUserSSHCertDuration: duration(stored.DefaultUserSSHCertDuration, user.Default),
+		MinHostSSHCertDuration:     duration(stored.MinHostSSHCertDuration, host.Min),
+		MaxHostSSHCertDuration:     duration(stored.MaxHostSSHCertDuration, host.Max),
+		DefaultHostSSHCertDuration: duration(stored.DefaultHostSSHCertDuration, host.Default),
+		DisableRenewal:             boolValueOrNull(stored.DisableRenewal, claims.DisableRenewal),
+		AllowRenewalAfterExpiry:    boolValueOrNull(stored.AllowRenewalAfterExpiry, claims.AllowRenewalAfterExpiry),
+		EnableSSHCA:                boolValueOrNull(stored.EnableSSHCA, sshEnabled),
+		DisableSmallstepExtensions: boolValueOrNull(stored.DisableSmallstepExtensions, claims.DisableSmallstepExtensions),
+	}
+}
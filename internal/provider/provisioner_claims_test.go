@@ -0,0 +1,122 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/z0link/terraform-provider-stepca/internal/client"
+)
+
+func TestValidateProvisionerClaims(t *testing.T) {
+	t.Parallel()
+
+	tls := func(lo, def, hi string) provisionerClaimsModel {
+		value := func(v string) types.String {
+			if v == "" {
+				return types.StringNull()
+			}
+			return types.StringValue(v)
+		}
+		return provisionerClaimsModel{
+			MinTLSCertDuration:     value(lo),
+			DefaultTLSCertDuration: value(def),
+			MaxTLSCertDuration:     value(hi),
+		}
+	}
+
+	tests := []struct {
+		name    string
+		claims  provisionerClaimsModel
+		wantErr bool
+	}{
+		{name: "ordered", claims: tls("5m", "24h", "2160h")},
+		{name: "equal", claims: tls("24h", "24h", "24h")},
+		{name: "partial", claims: tls("", "1h", "")},
+		{name: "min and max", claims: tls("1h", "", "30m"), wantErr: true},
+		{name: "default below min", claims: tls("1h", "30m", ""), wantErr: true},
+		{name: "default above max", claims: tls("", "48h", "24h"), wantErr: true},
+		{name: "days are not a duration unit", claims: tls("", "1d", ""), wantErr: true},
+		{name: "negative", claims: tls("-1h", "", ""), wantErr: true},
+		{
+			name: "ssh host",
+			claims: provisionerClaimsModel{
+				MinHostSSHCertDuration: types.StringValue("720h"),
+				MaxHostSSHCertDuration: types.StringValue("168h"),
+			},
+			wantErr: true,
+		},
+		{
+			name:   "unknown",
+			claims: provisionerClaimsModel{MinUserSSHCertDuration: types.StringUnknown(), MaxUserSSHCertDuration: types.StringValue("1h")},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if diags := validateProvisionerClaims(tc.claims); diags.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%t got %v", tc.wantErr, diags)
+			}
+		})
+	}
+}
+
+func TestProvisionerSetClaims(t *testing.T) {
+	t.Parallel()
+
+	var data provisionerResourceModel
+	data.setClaims(&client.ProvisionerClaims{SSH: &client.SSHClaims{}})
+	if data.Claims != nil {
+		t.Fatalf("expected no claims block for empty claims, got %#v", data.Claims)
+	}
+
+	data.Claims = &provisionerClaimsModel{
+		DefaultTLSCertDuration: types.StringValue("24h"),
+		MaxTLSCertDuration:     types.StringValue("2160h"),
+		EnableSSHCA:            types.BoolValue(true),
+		DisableRenewal:         types.BoolNull(),
+	}
+	sent := provisionerClaimsToClient(data.Claims)
+	if sent.X509 == nil || sent.X509.Durations.Default != "24h" || sent.SSH == nil || !sent.SSH.Enabled || sent.SSH.UserDurations != nil {
+		t.Fatalf("unexpected admin API claims: %#v", sent)
+	}
+
+	// step-ca normalizes durations.
+	data.setClaims(&client.ProvisionerClaims{
+		X509: &client.X509Claims{Enabled: true, Durations: &client.ProvisionerDurations{Default: "24h0m0s", Max: "1440h0m0s"}},
+		SSH:  &client.SSHClaims{Enabled: true},
+	})
+	c := data.Claims
+	if c.DefaultTLSCertDuration.ValueString() != "24h" || !c.EnableSSHCA.ValueBool() || !c.DisableRenewal.IsNull() || !c.MinTLSCertDuration.IsNull() {
+		t.Fatalf("equivalent values must keep their configured form: %#v", c)
+	}
+	if c.MaxTLSCertDuration.ValueString() != "1440h0m0s" {
+		t.Fatalf("a changed maximum must show up as drift, got %s", c.MaxTLSCertDuration)
+	}
+
+	data.setClaims(nil)
+	if data.Claims == nil || !data.Claims.DefaultTLSCertDuration.IsNull() || data.Claims.EnableSSHCA.ValueBool() {
+		t.Fatalf("claims removed in step-ca must show up as drift: %#v", data.Claims)
+	}
+}
+
+func TestProvisionerResourceUpdateClaims(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeProvisionerClient{getResp: &client.Provisioner{Name: "acme", Type: "ACME"}}
+	r := &provisionerResource{client: fake}
+	state := provisionerResourceModel{Name: types.StringValue("acme"), Type: types.StringValue("ACME"),
+		Claims: &provisionerClaimsModel{DefaultTLSCertDuration: types.StringValue("24h")}}
+	plan := provisionerResourceModel{Name: types.StringValue("acme"), Type: types.StringValue("ACME"),
+		Claims: &provisionerClaimsModel{DefaultTLSCertDuration: types.StringValue("72h")}}
+	updated, diags := r.updateProvisioner(context.Background(), &state, &plan)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %#v", diags)
+	}
+	if !fake.replaceCalled || fake.replaceInput.Claims == nil || fake.replaceInput.Claims.X509.Durations.Default != "72h" {
+		t.Fatalf("unexpected replace payload: %#v", fake.replaceInput.Claims)
+	}
+	if updated == nil || updated.Claims != plan.Claims {
+		t.Fatalf("unexpected updated state: %#v", updated)
+	}
+}
@@ -0,0 +1,1346 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/z0link/terraform-provider-stepca/internal/client"
+)
+
+// provisionerJWKModel configures a JWK provisioner. Without public_key the
+// provider generates the key pair and encrypts the private key with password.
+type provisionerJWKModel struct {
+	PublicKey       types.String `tfsdk:"public_key"`
+	EncryptedKey    types.String `tfsdk:"encrypted_key"`
+	Password        types.String `tfsdk:"password"`
+	PasswordVersion types.Int64  `tfsdk:"password_version"`
+}
+
+// provisionerOIDCModel configures an OIDC provisioner.
+type provisionerOIDCModel struct {
+	ClientID              types.String `tfsdk:"client_id"`
+	ClientSecret          types.String `tfsdk:"client_secret"`
+	ConfigurationEndpoint types.String `tfsdk:"configuration_endpoint"`
+	Admins                types.List   `tfsdk:"admins"`
+	Domains               types.List   `tfsdk:"domains"`
+	Groups                types.List   `tfsdk:"groups"`
+	ListenAddress         types.String `tfsdk:"listen_address"`
+	TenantID              types.String `tfsdk:"tenant_id"`
+}
+
+// provisionerACMEModel configures an ACME provisioner.
+type provisionerACMEModel struct {
+	ForceCN            types.Bool   `tfsdk:"force_cn"`
+	RequireEAB         types.Bool   `tfsdk:"require_eab"`
+	Challenges         types.List   `tfsdk:"challenges"`
+	AttestationFormats types.List   `tfsdk:"attestation_formats"`
+	AttestationRoots   types.String `tfsdk:"attestation_roots"`
+}
+
+// provisionerAWSModel configures an AWS provisioner.
+type provisionerAWSModel struct {
+	Accounts               types.List   `tfsdk:"accounts"`
+	InstanceAge            types.String `tfsdk:"instance_age"`
+	DisableCustomSANs      types.Bool   `tfsdk:"disable_custom_sans"`
+	DisableTrustOnFirstUse types.Bool   `tfsdk:"disable_trust_on_first_use"`
+	IIDRoots               types.String `tfsdk:"iid_roots"`
+}
+
+// provisionerGCPModel configures a GCP provisioner.
+type provisionerGCPModel struct {
+	ServiceAccounts types.List `tfsdk:"service_accounts"`
+	ProjectIDs      types.List `tfsdk:"project_ids"`
+}
+
+// provisionerAzureModel configures an Azure provisioner.
+type provisionerAzureModel struct {
+	TenantID        types.String `tfsdk:"tenant_id"`
+	ResourceGroups  types.List   `tfsdk:"resource_groups"`
+	SubscriptionIDs types.List   `tfsdk:"subscription_ids"`
+	ObjectIDs       types.List   `tfsdk:"object_ids"`
+}
+
+// provisionerX5CModel configures an X5C provisioner.
+type provisionerX5CModel struct {
+	Roots types.String `tfsdk:"roots"`
+}
+
+// provisionerK8sSAModel configures a K8sSA provisioner.
+type provisionerK8sSAModel struct {
+	PublicKeys types.List `tfsdk:"public_keys"`
+}
+
+// provisionerSSHPOPModel marks an SSHPOP provisioner, which has no settings.
+type provisionerSSHPOPModel struct{}
+
+// provisionerNebulaModel configures a Nebula provisioner.
+type provisionerNebulaModel struct {
+	Roots types.String `tfsdk:"roots"`
+}
+
+// provisionerSCEPModel configures a SCEP provisioner.
+type provisionerSCEPModel struct {
+	Challenge                     types.String `tfsdk:"challenge"`
+	ChallengeVersion              types.Int64  `tfsdk:"challenge_version"`
+	Capabilities                  types.List   `tfsdk:"capabilities"`
+	MinimumPublicKeyLength        types.Int64  `tfsdk:"minimum_public_key_length"`
+	IncludeRoot                   types.Bool   `tfsdk:"include_root"`
+	EncryptionAlgorithmIdentifier types.Int64  `tfsdk:"encryption_algorithm_identifier"`
+	DecrypterCertificate          types.String `tfsdk:"decrypter_certificate"`
+	DecrypterKey                  types.String `tfsdk:"decrypter_key"`
+	DecrypterKeyPassword          types.String `tfsdk:"decrypter_key_password"`
+	DecrypterKeyVersion           types.Int64  `tfsdk:"decrypter_key_version"`
+
+	// challenge, decrypterKey and decrypterKeyPassword are the write-only
+	// values from the configuration. They are sent to step-ca but never
+	// stored.
+	challenge            string
+	decrypterKey         string
+	decrypterKeyPassword string
+}
+
+// acmeChallenges and acmeAttestationFormats map the names used in the
+// configuration to the enum names of the admin API.
+var (
+	acmeChallenges = map[string]string{
+		"http-01":          "HTTP_01",
+		"dns-01":           "DNS_01",
+		"tls-alpn-01":      "TLS_ALPN_01",
+		"device-attest-01": "DEVICE_ATTEST_01",
+	}
+	acmeAttestationFormats = map[string]string{
+		"apple": "APPLE",
+		"step":  "STEP",
+		"tpm":   "TPM",
+	}
+)
+
+// provisionerDetailBlocks returns the type specific configuration blocks of
+// stepca_provisioner.
+func provisionerDetailBlocks() map[string]schema.Block {
+	return map[string]schema.Block{
+		"jwk": schema.SingleNestedBlock{
+			Description: "Configuration of a JWK provisioner. Required when type is JWK.",
+			Attributes: map[string]schema.Attribute{
+				"public_key": schema.StringAttribute{
+					Optional:      true,
+					Computed:      true,
+					Description:   "Public JWK as JSON. Omit it to have the provider generate a P-256 key pair.",
+					PlanModifiers: []planmodifier.String{generatedJWKPlanModifier{}},
+				},
+				"encrypted_key": schema.StringAttribute{
+					Optional:      true,
+					Computed:      true,
+					Description:   "Private JWK encrypted with the provisioner password as a compact JWE, as written by `step crypto jwk create`.",
+					PlanModifiers: []planmodifier.String{generatedJWKPlanModifier{}},
+				},
+				"password": schema.StringAttribute{
+					Optional:    true,
+					Sensitive:   true,
+					WriteOnly:   true,
+					Description: "Password used to encrypt a generated key. It is never stored in state.",
+				},
+				"password_version": schema.Int64Attribute{
+					Optional:    true,
+					Description: "Change it to generate a new key pair encrypted with a changed password.",
+				},
+			},
+		},
+		"oidc": schema.SingleNestedBlock{
+			Description: "Configuration of an OIDC provisioner. Required when type is OIDC.",
+			Attributes: map[string]schema.Attribute{
+				"client_id": schema.StringAttribute{
+					Optional:    true,
+					Description: "OAuth client ID registered with the identity provider. Required in the block.",
+				},
+				"client_secret": schema.StringAttribute{
+					Optional:    true,
+					Sensitive:   true,
+					Description: "OAuth client secret.",
+				},
+				"configuration_endpoint": schema.StringAttribute{
+					Optional:    true,
+					Description: "URL of the OpenID Connect discovery document. Required in the block.",
+				},
+				"admins": schema.ListAttribute{
+					Optional:    true,
+					ElementType: types.StringType,
+					Description: "Emails of users that may get certificates for any principal.",
+				},
+				"domains": schema.ListAttribute{
+					Optional:    true,
+					ElementType: types.StringType,
+					Description: "Email domains allowed to get certificates.",
+				},
+				"groups": schema.ListAttribute{
+					Optional:    true,
+					ElementType: types.StringType,
+					Description: "Groups allowed to get certificates.",
+				},
+				"listen_address": schema.StringAttribute{
+					Optional:    true,
+					Description: "Local address `step` listens on for the OAuth redirect, such as `:10000`.",
+				},
+				"tenant_id": schema.StringAttribute{
+					Optional:    true,
+					Description: "Tenant ID of an Azure AD identity provider.",
+				},
+			},
+		},
+		"acme": schema.SingleNestedBlock{
+			Description: "Configuration of an ACME provisioner.",
+			Attributes: map[string]schema.Attribute{
+				"force_cn": schema.BoolAttribute{
+					Optional:    true,
+					Description: "Copy the first SAN into the subject common name of issued certificates.",
+				},
+				"require_eab": schema.BoolAttribute{
+					Optional:    true,
+					Description: "Require External Account Binding when ACME accounts are created.",
+				},
+				"challenges": schema.ListAttribute{
+					Optional:    true,
+					ElementType: types.StringType,
+					Description: "Enabled challenges out of http-01, dns-01, tls-alpn-01 and device-attest-01. step-ca enables all but device-attest-01 when unset.",
+				},
+				"attestation_formats": schema.ListAttribute{
+					Optional:    true,
+					ElementType: types.StringType,
+					Description: "Attestation formats accepted by device-attest-01 out of apple, step and tpm.",
+				},
+				"attestation_roots": schema.StringAttribute{
+					Optional:    true,
+					Description: "PEM bundle of the roots that attestation certificates must chain to.",
+				},
+			},
+		},
+		"aws": schema.SingleNestedBlock{
+			Description: "Configuration of an AWS provisioner.",
+			Attributes: map[string]schema.Attribute{
+				"accounts": schema.ListAttribute{
+					Optional:    true,
+					ElementType: types.StringType,
+					Description: "AWS account IDs whose instances may get certificates. Any account is accepted when unset.",
+				},
+				"instance_age": schema.StringAttribute{
+					Optional:    true,
+					Description: "Maximum age of an instance as a duration, such as `1h`.",
+				},
+				"disable_custom_sans": schema.BoolAttribute{
+					Optional:    true,
+					Description: "Only allow the instance's own names as SANs.",
+				},
+				"disable_trust_on_first_use": schema.BoolAttribute{
+					Optional:    true,
+					Description: "Allow an instance to get more than one certificate.",
+				},
+				"iid_roots": schema.StringAttribute{
+					Optional:    true,
+					Description: "PEM bundle of the certificates instance identity documents are verified with. Defaults to the AWS certificates built into step-ca.",
+				},
+			},
+		},
+		"gcp": schema.SingleNestedBlock{
+			Description: "Configuration of a GCP provisioner.",
+			Attributes: map[string]schema.Attribute{
+				"service_accounts": schema.ListAttribute{
+					Optional:    true,
+					ElementType: types.StringType,
+					Description: "Service accounts whose instances may get certificates.",
+				},
+				"project_ids": schema.ListAttribute{
+					Optional:    true,
+					ElementType: types.StringType,
+					Description: "Projects whose instances may get certificates.",
+				},
+			},
+		},
+		"azure": schema.SingleNestedBlock{
+			Description: "Configuration of an Azure provisioner. Required when type is Azure.",
+			Attributes: map[string]schema.Attribute{
+				"tenant_id": schema.StringAttribute{
+					Optional:    true,
+					Description: "Microsoft Entra tenant that issues the managed identity tokens. Required in the block.",
+				},
+				"resource_groups": schema.ListAttribute{
+					Optional:    true,
+					ElementType: types.StringType,
+					Description: "Resource groups whose virtual machines may get certificates.",
+				},
+				"subscription_ids": schema.ListAttribute{
+					Optional:    true,
+					ElementType: types.StringType,
+					Description: "Subscriptions whose virtual machines may get certificates.",
+				},
+				"object_ids": schema.ListAttribute{
+					Optional:    true,
+					ElementType: types.StringType,
+					Description: "Object IDs of the managed identities that may get certificates.",
+				},
+			},
+		},
+		"x5c": schema.SingleNestedBlock{
+			Description: "Configuration of an X5C provisioner. Required when type is X5C.",
+			Attributes: map[string]schema.Attribute{
+				"roots": schema.StringAttribute{
+					Optional:    true,
+					Description: "PEM bundle of the roots that token signing certificates must chain to. Required in the block.",
+				},
+			},
+		},
+		"k8ssa": schema.SingleNestedBlock{
+			Description: "Configuration of a K8sSA provisioner. Required when type is K8sSA.",
+			Attributes: map[string]schema.Attribute{
+				"public_keys": schema.ListAttribute{
+					Optional:    true,
+					ElementType: types.StringType,
+					Description: "PEM public keys that verify service account tokens, such as the cluster's sa.pub. Required in the block.",
+				},
+			},
+		},
+		"sshpop": schema.SingleNestedBlock{
+			Description: "Marks an SSHPOP provisioner, which has no settings.",
+		},
+		"scep": schema.SingleNestedBlock{
+			Description: "Configuration of a SCEP provisioner.",
+			Attributes: map[string]schema.Attribute{
+				"challenge": schema.StringAttribute{
+					Optional:    true,
+					Sensitive:   true,
+					WriteOnly:   true,
+					Description: "Shared secret clients present when they enroll. It is never stored in state.",
+				},
+				"challenge_version": schema.Int64Attribute{
+					Optional:    true,
+					Description: "Change it to send a changed challenge to step-ca.",
+				},
+				"capabilities": schema.ListAttribute{
+					Optional:    true,
+					ElementType: types.StringType,
+					Description: "SCEP capabilities to advertise, such as AES and SHA-256.",
+				},
+				"minimum_public_key_length": schema.Int64Attribute{
+					Optional:    true,
+					Description: "Minimum length in bits of RSA keys, including the decrypter key.",
+				},
+				"include_root": schema.BoolAttribute{
+					Optional:    true,
+					Description: "Include the root in the CA certificates returned to clients.",
+				},
+				"encryption_algorithm_identifier": schema.Int64Attribute{
+					Optional:    true,
+					Description: "Encryption of responses: 0 DES-CBC, 1 AES-128-CBC, 2 AES-256-CBC, 3 AES-128-GCM or 4 AES-256-GCM.",
+				},
+				"decrypter_certificate": schema.StringAttribute{
+					Optional:    true,
+					Description: "PEM certificate of the RSA key that decrypts requests. Defaults to the intermediate.",
+				},
+				"decrypter_key": schema.StringAttribute{
+					Optional:    true,
+					Sensitive:   true,
+					WriteOnly:   true,
+					Description: "PEM private key matching decrypter_certificate. It is never stored in state.",
+				},
+				"decrypter_key_password": schema.StringAttribute{
+					Optional:    true,
+					Sensitive:   true,
+					WriteOnly:   true,
+					Description: "Password of an encrypted decrypter_key. It is never stored in state.",
+				},
+				"decrypter_key_version": schema.Int64Attribute{
+					Optional:    true,
+					Description: "Change it to send a changed decrypter_key or decrypter_key_password to step-ca.",
+				},
+			},
+		},
+		"nebula": schema.SingleNestedBlock{
+			Description: "Configuration of a Nebula provisioner. Required when type is Nebula.",
+			Attributes: map[string]schema.Attribute{
+				"roots": schema.StringAttribute{
+					Optional:    true,
+					Description: "PEM bundle of the Nebula CA certificates that host certificates must be signed by. Required in the block.",
+				},
+			},
+		},
+	}
+}
+
+// provisionerDetailTypes maps each detail block to the provisioner type it
+// configures.
+var provisionerDetailTypes = map[string]string{
+	"jwk":    "JWK",
+	"oidc":   "OIDC",
+	"acme":   "ACME",
+	"aws":    "AWS",
+	"gcp":    "GCP",
+	"azure":  "Azure",
+	"x5c":    "X5C",
+	"k8ssa":  "K8sSA",
+	"sshpop": "SSHPOP",
+	"scep":   "SCEP",
+	"nebula": "Nebula",
+}
+
+// configuredDetailBlocks returns the names of the detail blocks set in data.
+func (m provisionerResourceModel) configuredDetailBlocks() []string {
+	var blocks []string
+	if m.JWK != nil {
+		blocks = append(blocks, "jwk")
+	}
+	if m.OIDC != nil {
+		blocks = append(blocks, "oidc")
+	}
+	if m.ACME != nil {
+		blocks = append(blocks, "acme")
+	}
+	if m.AWS != nil {
+		blocks = append(blocks, "aws")
+	}
+	if m.GCP != nil {
+		blocks = append(blocks, "gcp")
+	}
+	if m.Azure != nil {
+		blocks = append(blocks, "azure")
+	}
+	if m.X5C != nil {
+		blocks = append(blocks, "x5c")
+	}
+	if m.K8sSA != nil {
+		blocks = append(blocks, "k8ssa")
+	}
+	if m.SSHPOP != nil {
+		blocks = append(blocks, "sshpop")
+	}
+	if m.SCEP != nil {
+		blocks = append(blocks, "scep")
+	}
+	if m.Nebula != nil {
+		blocks = append(blocks, "nebula")
+	}
+	return blocks
+}
+
+// validateProvisionerDetails checks that the detail blocks match the
+// provisioner type and are complete.
+func validateProvisionerDetails(data provisionerResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if data.Type.IsUnknown() {
+		return diags
+	}
+	typ := data.Type.ValueString()
+	for _, block := range data.configuredDetailBlocks() {
+		if want := provisionerDetailTypes[block]; want != typ {
+			diags.AddAttributeError(path.Root(block), "invalid provisioner configuration",
+				fmt.Sprintf("the %s block can only be used with type = %q, got %q", block, want, typ))
+		}
+	}
+	if typ == "JWK" && data.JWK == nil {
+		diags.AddAttributeError(path.Root("type"), "invalid provisioner configuration",
+			"JWK provisioners need a jwk block with either public_key or a password to generate a key")
+	}
+	if typ == "OIDC" && data.OIDC == nil {
+		diags.AddAttributeError(path.Root("type"), "invalid provisioner configuration",
+			"OIDC provisioners need an oidc block with client_id and configuration_endpoint")
+	}
+	if typ == "Azure" && data.Azure == nil {
+		diags.AddAttributeError(path.Root("type"), "invalid provisioner configuration",
+			"Azure provisioners need an azure block with tenant_id")
+	}
+	if typ == "X5C" && data.X5C == nil {
+		diags.AddAttributeError(path.Root("type"), "invalid provisioner configuration",
+			"X5C provisioners need an x5c block with roots")
+	}
+	if typ == "K8sSA" && data.K8sSA == nil {
+		diags.AddAttributeError(path.Root("type"), "invalid provisioner configuration",
+			"K8sSA provisioners need a k8ssa block with public_keys")
+	}
+	if typ == "SSHPOP" && data.SSHPOP == nil {
+		diags.AddAttributeError(path.Root("type"), "invalid provisioner configuration",
+			"SSHPOP provisioners need an empty sshpop block")
+	}
+	if typ == "Nebula" && data.Nebula == nil {
+		diags.AddAttributeError(path.Root("type"), "invalid provisioner configuration",
+			"Nebula provisioners need a nebula block with roots")
+	}
+	if data.JWK != nil {
+		diags.Append(validateProvisionerJWK(*data.JWK)...)
+	}
+	if data.OIDC != nil {
+		diags.Append(validateProvisionerOIDC(*data.OIDC)...)
+	}
+	if data.ACME != nil {
+		diags.Append(validateProvisionerACME(*data.ACME)...)
+	}
+	if data.AWS != nil {
+		diags.Append(validateProvisionerAWS(*data.AWS)...)
+	}
+	if data.Azure != nil && data.Azure.TenantID.IsNull() {
+		diags.AddAttributeError(path.Root("azure").AtName("tenant_id"), "invalid provisioner configuration",
+			"tenant_id is required in the azure block")
+	}
+	if data.X5C != nil {
+		diags.Append(validateProvisionerX5C(*data.X5C)...)
+	}
+	if data.K8sSA != nil {
+		diags.Append(validateProvisionerK8sSA(*data.K8sSA)...)
+	}
+	if data.SCEP != nil {
+		diags.Append(validateProvisionerSCEP(*data.SCEP)...)
+	}
+	if data.Nebula != nil {
+		diags.Append(validateProvisionerNebula(*data.Nebula, time.Now())...)
+	}
+	return diags
+}
+
+func validateProvisionerJWK(m provisionerJWKModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if m.PublicKey.IsUnknown() || m.EncryptedKey.IsUnknown() || m.Password.IsUnknown() {
+		return diags
+	}
+	block := path.Root("jwk")
+	switch {
+	case m.PublicKey.IsNull() && !m.EncryptedKey.IsNull():
+		diags.AddAttributeError(block.AtName("encrypted_key"), "invalid provisioner configuration",
+			"encrypted_key requires the matching public_key")
+	case m.PublicKey.IsNull() && m.Password.IsNull():
+		diags.AddAttributeError(block, "invalid provisioner configuration",
+			"set public_key, or password to have the provider generate the key")
+	case !m.PublicKey.IsNull() && !m.Password.IsNull():
+		diags.AddAttributeError(block.AtName("password"), "invalid provisioner configuration",
+			"password is only used to encrypt a generated key; omit it when public_key is set")
+	case !m.PublicKey.IsNull() && !m.PasswordVersion.IsNull():
+		diags.AddAttributeError(block.AtName("password_version"), "invalid provisioner configuration",
+			"password_version only applies to a generated key; omit it when public_key is set")
+	}
+	if !m.PublicKey.IsNull() {
+		var jwk map[string]any
+		if err := json.Unmarshal([]byte(m.PublicKey.ValueString()), &jwk); err != nil || jwk["kty"] == nil {
+			diags.AddAttributeError(block.AtName("public_key"), "invalid provisioner configuration",
+				"public_key must be a JWK in JSON form, such as the output of `step crypto jwk create`")
+		}
+	}
+	return diags
+}
+
+func validateProvisionerOIDC(m provisionerOIDCModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	block := path.Root("oidc")
+	if m.ClientID.IsNull() {
+		diags.AddAttributeError(block.AtName("client_id"), "invalid provisioner configuration",
+			"client_id is required in the oidc block")
+	}
+	switch {
+	case m.ConfigurationEndpoint.IsUnknown():
+	case m.ConfigurationEndpoint.IsNull():
+		diags.AddAttributeError(block.AtName("configuration_endpoint"), "invalid provisioner configuration",
+			"configuration_endpoint is required in the oidc block")
+	default:
+		u, err := url.Parse(m.ConfigurationEndpoint.ValueString())
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			diags.AddAttributeError(block.AtName("configuration_endpoint"), "invalid provisioner configuration",
+				"configuration_endpoint must be an http or https URL, such as https://accounts.google.com/.well-known/openid-configuration")
+		}
+	}
+	return diags
+}
+
+func validateProvisionerACME(m provisionerACMEModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	block := path.Root("acme")
+	challenges := listStrings(m.Challenges)
+	for _, c := range challenges {
+		if _, ok := acmeChallenges[c]; !ok {
+			diags.AddAttributeError(block.AtName("challenges"), "invalid provisioner configuration",
+				fmt.Sprintf("unknown challenge %q, expected one of http-01, dns-01, tls-alpn-01 or device-attest-01", c))
+		}
+	}
+	for _, f := range listStrings(m.AttestationFormats) {
+		if _, ok := acmeAttestationFormats[f]; !ok {
+			diags.AddAttributeError(block.AtName("attestation_formats"), "invalid provisioner configuration",
+				fmt.Sprintf("unknown attestation format %q, expected one of apple, step or tpm", f))
+		}
+	}
+	if v, ok := optionalStringValue(m.AttestationRoots); ok {
+		if _, err := parseCertificates(v); err != nil {
+			diags.AddAttributeError(block.AtName("attestation_roots"), "invalid provisioner configuration",
+				fmt.Sprintf("attestation_roots must be a PEM bundle of certificates: %s", err))
+		}
+	}
+	attestation := len(listStrings(m.AttestationFormats)) > 0 || !m.AttestationRoots.IsNull()
+	if attestation && !m.Challenges.IsUnknown() && !slices.Contains(challenges, "device-attest-01") {
+		diags.AddAttributeError(block.AtName("challenges"), "invalid provisioner configuration",
+			"attestation_formats and attestation_roots are only used by the device-attest-01 challenge; add it to challenges")
+	}
+	return diags
+}
+
+func validateProvisionerAWS(m provisionerAWSModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	block := path.Root("aws")
+	if v, ok := optionalStringValue(m.InstanceAge); ok {
+		if d, err := time.ParseDuration(v); err != nil || d <= 0 {
+			diags.AddAttributeError(block.AtName("instance_age"), "invalid provisioner configuration",
+				fmt.Sprintf("instance_age must be a positive duration such as 1h, got %q", v))
+		}
+	}
+	if v, ok := optionalStringValue(m.IIDRoots); ok {
+		if _, err := parseCertificates(v); err != nil {
+			diags.AddAttributeError(block.AtName("iid_roots"), "invalid provisioner configuration",
+				fmt.Sprintf("iid_roots must be a PEM bundle of certificates: %s", err))
+		}
+	}
+	return diags
+}
+
+func validateProvisionerX5C(m provisionerX5CModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	target := path.Root("x5c").AtName("roots")
+	switch {
+	case m.Roots.IsUnknown():
+	case m.Roots.IsNull():
+		diags.AddAttributeError(target, "invalid provisioner configuration", "roots is required in the x5c block")
+	default:
+		if _, err := parseCertificates(m.Roots.ValueString()); err != nil {
+			diags.AddAttributeError(target, "invalid provisioner configuration",
+				fmt.Sprintf("roots must be a PEM bundle of certificates: %s", err))
+		}
+	}
+	return diags
+}
+
+func validateProvisionerK8sSA(m provisionerK8sSAModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	target := path.Root("k8ssa").AtName("public_keys")
+	if m.PublicKeys.IsUnknown() {
+		return diags
+	}
+	if m.PublicKeys.IsNull() || len(m.PublicKeys.Elements()) == 0 {
+		diags.AddAttributeError(target, "invalid provisioner configuration", "public_keys is required in the k8ssa block")
+	}
+	for _, key := range listStrings(m.PublicKeys) {
+		if _, err := parsePublicKeysPEM(key); err != nil {
+			diags.AddAttributeError(target, "invalid provisioner configuration",
+				fmt.Sprintf("public_keys must hold PEM public keys: %s", err))
+		}
+	}
+	return diags
+}
+
+func validateProvisionerSCEP(m provisionerSCEPModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	block := path.Root("scep")
+	if v := m.EncryptionAlgorithmIdentifier; !v.IsNull() && !v.IsUnknown() && (v.ValueInt64() < 0 || v.ValueInt64() > 4) {
+		diags.AddAttributeError(block.AtName("encryption_algorithm_identifier"), "invalid provisioner configuration",
+			fmt.Sprintf("encryption_algorithm_identifier must be between 0 and 4, got %d", v.ValueInt64()))
+	}
+	if v := m.MinimumPublicKeyLength; !v.IsNull() && !v.IsUnknown() && v.ValueInt64() < 0 {
+		diags.AddAttributeError(block.AtName("minimum_public_key_length"), "invalid provisioner configuration",
+			"minimum_public_key_length cannot be negative")
+	}
+	if m.DecrypterCertificate.IsUnknown() || m.DecrypterKey.IsUnknown() || m.DecrypterKeyPassword.IsUnknown() {
+		return diags
+	}
+	switch {
+	case m.DecrypterCertificate.IsNull() != m.DecrypterKey.IsNull():
+		diags.AddAttributeError(block, "invalid provisioner configuration",
+			"decrypter_certificate and decrypter_key must be set together")
+		return diags
+	case m.DecrypterKey.IsNull() && !m.DecrypterKeyPassword.IsNull():
+		diags.AddAttributeError(block.AtName("decrypter_key_password"), "invalid provisioner configuration",
+			"decrypter_key_password requires decrypter_key")
+		return diags
+	case m.DecrypterCertificate.IsNull():
+		return diags
+	}
+
+	cert, err := parseCertificate(m.DecrypterCertificate.ValueString())
+	if err != nil {
+		diags.AddAttributeError(block.AtName("decrypter_certificate"), "invalid provisioner configuration",
+			fmt.Sprintf("decrypter_certificate must be a PEM certificate: %s", err))
+		return diags
+	}
+	rsaKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		diags.AddAttributeError(block.AtName("decrypter_certificate"), "invalid provisioner configuration",
+			fmt.Sprintf("SCEP decrypts requests with RSA keys, the decrypter certificate has a %s key", cert.PublicKeyAlgorithm))
+		return diags
+	}
+	if bits, minBits := rsaKey.N.BitLen(), m.MinimumPublicKeyLength.ValueInt64(); int64(bits) < minBits {
+		diags.AddAttributeError(block.AtName("decrypter_certificate"), "invalid provisioner configuration",
+			fmt.Sprintf("the decrypter key has %d bits, less than minimum_public_key_length %d", bits, minBits))
+	}
+
+	// Encrypted keys are checked by step-ca when it loads them.
+	if keyBlock, _ := pem.Decode([]byte(m.DecrypterKey.ValueString())); keyBlock != nil &&
+		(keyBlock.Type == "ENCRYPTED PRIVATE KEY" || keyBlock.Headers["Proc-Type"] == "4,ENCRYPTED") {
+		if m.DecrypterKeyPassword.IsNull() {
+			diags.AddAttributeError(block.AtName("decrypter_key_password"), "invalid provisioner configuration",
+				"decrypter_key is encrypted; set decrypter_key_password")
+		}
+		return diags
+	}
+	key, err := parsePrivateKeyPEM(m.DecrypterKey.ValueString())
+	if err != nil {
+		diags.AddAttributeError(block.AtName("decrypter_key"), "invalid provisioner configuration",
+			fmt.Sprintf("decrypter_key must be a PEM private key: %s", err))
+		return diags
+	}
+	if !rsaKey.Equal(key.Public()) {
+		diags.AddAttributeError(block.AtName("decrypter_key"), "invalid provisioner configuration",
+			"decrypter_key does not match the public key of decrypter_certificate")
+	}
+	return diags
+}
+
+func validateProvisionerNebula(m provisionerNebulaModel, now time.Time) diag.Diagnostics {
+	var diags diag.Diagnostics
+	target := path.Root("nebula").AtName("roots")
+	if m.Roots.IsUnknown() {
+		return diags
+	}
+	if m.Roots.IsNull() {
+		diags.AddAttributeError(target, "invalid provisioner configuration", "roots is required in the nebula block")
+		return diags
+	}
+	certs, err := parseNebulaCertificates(m.Roots.ValueString())
+	if err != nil {
+		diags.AddAttributeError(target, "invalid provisioner configuration", fmt.Sprintf("roots must hold Nebula CA certificates: %s", err))
+		return diags
+	}
+	for _, cert := range certs {
+		if !cert.IsCA {
+			diags.AddAttributeError(target, "invalid provisioner configuration",
+				fmt.Sprintf("the Nebula certificate %q is a host certificate, not a CA", cert.Name))
+		} else if !cert.NotAfter.IsZero() && cert.NotAfter.Before(now) {
+			diags.AddAttributeWarning(target, "expired Nebula CA",
+				fmt.Sprintf("the Nebula CA %q expired at %s", cert.Name, cert.NotAfter.Format(time.RFC3339)))
+		}
+	}
+	return diags
+}
+
+// prepareJWK fills in the computed key attributes before the provisioner is
+// sent to step-ca, generating a key pair when none is configured. password
+// is the write-only password from the configuration.
+func prepareJWK(m *provisionerJWKModel, password types.String) diag.Diagnostics {
+	var diags diag.Diagnostics
+	m.Password = types.StringNull()
+	if !m.PublicKey.IsNull() && !m.PublicKey.IsUnknown() {
+		if m.EncryptedKey.IsUnknown() {
+			m.EncryptedKey = types.StringNull()
+		}
+		return diags
+	}
+	key, err := client.GenerateJWKProvisionerKey(password.ValueString())
+	if err != nil {
+		diags.AddAttributeError(path.Root("jwk").AtName("password"), "key generation failed", err.Error())
+		return diags
+	}
+	m.PublicKey = types.StringValue(key.PublicKey)
+	m.EncryptedKey = types.StringValue(key.EncryptedKey)
+	return diags
+}
+
+// generatedJWKPlanModifier keeps a generated key pair from one plan to the
+// next like UseStateForUnknown. The write-only password that encrypts it is
+// not visible in a plan, so changing password_version leaves the key pair
+// unknown instead and prepareJWK generates a new one with the current
+// password. Terraform never learns the old password, so the existing key
+// cannot be re-encrypted.
+type generatedJWKPlanModifier struct{}
+
+func (m generatedJWKPlanModifier) Description(ctx context.Context) string {
+	return "Keeps the generated key pair unless password_version changes."
+}
+
+func (m generatedJWKPlanModifier) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m generatedJWKPlanModifier) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	if req.StateValue.IsNull() || !req.PlanValue.IsUnknown() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	var publicKey types.String
+	var planned, stored types.Int64
+	block := path.Root("jwk")
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, block.AtName("public_key"), &publicKey)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, block.AtName("password_version"), &planned)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, block.AtName("password_version"), &stored)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if publicKey.IsNull() && !planned.Equal(stored) {
+		return
+	}
+	resp.PlanValue = req.StateValue
+}
+
+// provisionerDetailsToClient converts the detail blocks into the admin API
+// representation.
+func provisionerDetailsToClient(data provisionerResourceModel) *client.ProvisionerDetails {
+	if len(data.configuredDetailBlocks()) == 0 {
+		return nil
+	}
+	details := &client.ProvisionerDetails{}
+	if data.JWK != nil {
+		details.JWK = &client.JWKProvisioner{PublicKey: []byte(data.JWK.PublicKey.ValueString())}
+		if v, ok := optionalStringValue(data.JWK.EncryptedKey); ok {
+			details.JWK.EncryptedPrivateKey = []byte(v)
+		}
+	}
+	if data.OIDC != nil {
+		details.OIDC = &client.OIDCProvisioner{
+			ClientID:              data.OIDC.ClientID.ValueString(),
+			ClientSecret:          data.OIDC.ClientSecret.ValueString(),
+			ConfigurationEndpoint: data.OIDC.ConfigurationEndpoint.ValueString(),
+			Admins:                listStrings(data.OIDC.Admins),
+			Domains:               listStrings(data.OIDC.Domains),
+			Groups:                listStrings(data.OIDC.Groups),
+			ListenAddress:         data.OIDC.ListenAddress.ValueString(),
+			TenantID:              data.OIDC.TenantID.ValueString(),
+		}
+	}
+	if data.ACME != nil {
+		details.ACME = &client.ACMEProvisioner{
+			ForceCN:            boolFromOptional(data.ACME.ForceCN),
+			RequireEAB:         boolFromOptional(data.ACME.RequireEAB),
+			Challenges:         mapNames(listStrings(data.ACME.Challenges), acmeChallenges),
+			AttestationFormats: mapNames(listStrings(data.ACME.AttestationFormats), acmeAttestationFormats),
+		}
+		if v, ok := optionalStringValue(data.ACME.AttestationRoots); ok {
+			details.ACME.AttestationRoots = []byte(v)
+		}
+	}
+	if data.AWS != nil {
+		details.AWS = &client.AWSProvisioner{
+			Accounts:               listStrings(data.AWS.Accounts),
+			DisableCustomSANs:      boolFromOptional(data.AWS.DisableCustomSANs),
+			DisableTrustOnFirstUse: boolFromOptional(data.AWS.DisableTrustOnFirstUse),
+			InstanceAge:            data.AWS.InstanceAge.ValueString(),
+			IIDRoots:               splitPEM(data.AWS.IIDRoots.ValueString()),
+		}
+	}
+	if data.GCP != nil {
+		details.GCP = &client.GCPProvisioner{
+			ServiceAccounts: listStrings(data.GCP.ServiceAccounts),
+			ProjectIDs:      listStrings(data.GCP.ProjectIDs),
+		}
+	}
+	if data.Azure != nil {
+		details.Azure = &client.AzureProvisioner{
+			TenantID:        data.Azure.TenantID.ValueString(),
+			ResourceGroups:  listStrings(data.Azure.ResourceGroups),
+			SubscriptionIDs: listStrings(data.Azure.SubscriptionIDs),
+			ObjectIDs:       listStrings(data.Azure.ObjectIDs),
+		}
+	}
+	if data.X5C != nil {
+		details.X5C = &client.X5CProvisioner{Roots: pemBytes(splitPEM(data.X5C.Roots.ValueString()))}
+	}
+	if data.K8sSA != nil {
+		details.K8sSA = &client.K8sSAProvisioner{PublicKeys: pemBytes(listStrings(data.K8sSA.PublicKeys))}
+	}
+	if data.SSHPOP != nil {
+		details.SSHPOP = &client.SSHPOPProvisioner{}
+	}
+	if data.SCEP != nil {
+		details.SCEP = &client.SCEPProvisioner{
+			Challenge:                     data.SCEP.challenge,
+			Capabilities:                  listStrings(data.SCEP.Capabilities),
+			MinimumPublicKeyLength:        int(data.SCEP.MinimumPublicKeyLength.ValueInt64()),
+			IncludeRoot:                   boolFromOptional(data.SCEP.IncludeRoot),
+			EncryptionAlgorithmIdentifier: int(data.SCEP.EncryptionAlgorithmIdentifier.ValueInt64()),
+		}
+		if v, ok := optionalStringValue(data.SCEP.DecrypterCertificate); ok {
+			details.SCEP.Decrypter = &client.SCEPDecrypter{
+				Certificate: []byte(v),
+				Key:         []byte(data.SCEP.decrypterKey),
+				KeyPassword: data.SCEP.decrypterKeyPassword,
+			}
+		}
+	}
+	if data.Nebula != nil {
+		details.Nebula = &client.NebulaProvisioner{Roots: pemBytes(splitPEM(data.Nebula.Roots.ValueString()))}
+	}
+	return details
+}
+
+// provisionerDetailsChanged reports whether the detail blocks of plan differ
+// from state. The write-only values are never in state, so they are left out
+// of the comparison; their version arguments ask for them to be sent again.
+func provisionerDetailsChanged(plan, state provisionerResourceModel) bool {
+	if plan.SCEP != nil && state.SCEP != nil &&
+		(!plan.SCEP.ChallengeVersion.Equal(state.SCEP.ChallengeVersion) ||
+			!plan.SCEP.DecrypterKeyVersion.Equal(state.SCEP.DecrypterKeyVersion)) {
+		return true
+	}
+	return !reflect.DeepEqual(withoutWriteOnlyDetails(provisionerDetailsToClient(plan)),
+		withoutWriteOnlyDetails(provisionerDetailsToClient(state)))
+}
+
+// withoutWriteOnlyDetails blanks the write-only values in details.
+func withoutWriteOnlyDetails(details *client.ProvisionerDetails) *client.ProvisionerDetails {
+	if details == nil || details.SCEP == nil {
+		return details
+	}
+	scep := *details.SCEP
+	scep.Challenge = ""
+	if scep.Decrypter != nil {
+		decrypter := *scep.Decrypter
+		decrypter.Key = nil
+		decrypter.KeyPassword = ""
+		scep.Decrypter = &decrypter
+	}
+	out := *details
+	out.SCEP = &scep
+	return &out
+}
+
+// setDetails refreshes the detail blocks from step-ca.
+func (m *provisionerResourceModel) setDetails(details *client.ProvisionerDetails) {
+	if details == nil {
+		details = &client.ProvisionerDetails{}
+	}
+	m.JWK = jwkModelFromClient(m.JWK, details.JWK)
+	m.OIDC = oidcModelFromClient(m.OIDC, details.OIDC)
+	m.ACME = acmeModelFromClient(m.ACME, details.ACME)
+	m.AWS = awsModelFromClient(m.AWS, details.AWS)
+	m.GCP = gcpModelFromClient(m.GCP, details.GCP)
+	m.Azure = azureModelFromClient(m.Azure, details.Azure)
+	m.X5C = x5cModelFromClient(m.X5C, details.X5C)
+	m.K8sSA = k8ssaModelFromClient(m.K8sSA, details.K8sSA)
+	m.SSHPOP = sshpopModelFromClient(details.SSHPOP)
+	m.SCEP = scepModelFromClient(m.SCEP, details.SCEP)
+	m.Nebula = nebulaModelFromClient(m.Nebula, details.Nebula)
+}
+
+// jwkModelFromClient converts a JWK configuration read from step-ca. A public
+// key that is the same JWK as the stored one keeps its stored formatting.
+func jwkModelFromClient(stored *provisionerJWKModel, jwk *client.JWKProvisioner) *provisionerJWKModel {
+	if jwk == nil {
+		return nil
+	}
+	publicKey := types.StringValue(string(jwk.PublicKey))
+	if stored != nil && jsonEquivalent(stored.PublicKey.ValueString(), publicKey.ValueString()) {
+		publicKey = stored.PublicKey
+	}
+	passwordVersion := types.Int64Null()
+	if stored != nil {
+		passwordVersion = stored.PasswordVersion
+	}
+	return &provisionerJWKModel{
+		PublicKey:       publicKey,
+		EncryptedKey:    stringValueOrNull(string(jwk.EncryptedPrivateKey)),
+		Password:        types.StringNull(),
+		PasswordVersion: passwordVersion,
+	}
+}
+
+// sshpopModelFromClient returns an empty block for SSHPOP provisioners, which
+// have no settings.
+func sshpopModelFromClient(sshpop *client.SSHPOPProvisioner) *provisionerSSHPOPModel {
+	if sshpop == nil {
+		return nil
+	}
+	return &provisionerSSHPOPModel{}
+}
+
+func oidcModelFromClient(stored *provisionerOIDCModel, oidc *client.OIDCProvisioner) *provisionerOIDCModel {
+	if oidc == nil {
+		return nil
+	}
+	if stored == nil {
+		stored = &provisionerOIDCModel{}
+	}
+	return &provisionerOIDCModel{
+		ClientID:              stringValueOrNull(oidc.ClientID),
+		ClientSecret:          stringValueOrNull(oidc.ClientSecret),
+		ConfigurationEndpoint: stringValueOrNull(oidc.ConfigurationEndpoint),
+		Admins:                stringListValue(stored.Admins, oidc.Admins),
+		Domains:               stringListValue(stored.Domains, oidc.Domains),
+		Groups:                stringListValue(stored.Groups, oidc.Groups),
+		ListenAddress:         stringValueOrNull(oidc.ListenAddress),
+		TenantID:              stringValueOrNull(oidc.TenantID),
+	}
+}
+
+// acmeModelFromClient converts an ACME configuration read from step-ca. step-ca
+// reports the default configuration for provisioners created without an acme
+// block, which keeps the block absent.
+func acmeModelFromClient(stored *provisionerACMEModel, acme *client.ACMEProvisioner) *provisionerACMEModel {
+	if acme == nil || (stored == nil && reflect.DeepEqual(*acme, client.ACMEProvisioner{})) {
+		return nil
+	}
+	if stored == nil {
+		stored = &provisionerACMEModel{}
+	}
+	roots := stringValueOrNull(string(acme.AttestationRoots))
+	if sameCertificates(stored.AttestationRoots.ValueString(), roots.ValueString()) {
+		roots = stored.AttestationRoots
+	}
+	return &provisionerACMEModel{
+		ForceCN:            boolValueOrNull(stored.ForceCN, acme.ForceCN),
+		RequireEAB:         boolValueOrNull(stored.RequireEAB, acme.RequireEAB),
+		Challenges:         stringListValue(stored.Challenges, configNames(acme.Challenges, acmeChallenges)),
+		AttestationFormats: stringListValue(stored.AttestationFormats, configNames(acme.AttestationFormats, acmeAttestationFormats)),
+		AttestationRoots:   roots,
+	}
+}
+
+// awsModelFromClient converts an AWS configuration read from step-ca. Like
+// ACME, the default configuration keeps an absent block absent.
+func awsModelFromClient(stored *provisionerAWSModel, aws *client.AWSProvisioner) *provisionerAWSModel {
+	if aws == nil || (stored == nil && reflect.DeepEqual(*aws, client.AWSProvisioner{})) {
+		return nil
+	}
+	if stored == nil {
+		stored = &provisionerAWSModel{}
+	}
+	instanceAge := stringValueOrNull(aws.InstanceAge)
+	if sameDuration(stored.InstanceAge.ValueString(), aws.InstanceAge) {
+		instanceAge = stored.InstanceAge
+	}
+	roots := stringValueOrNull(joinPEM(aws.IIDRoots))
+	if sameCertificates(stored.IIDRoots.ValueString(), roots.ValueString()) {
+		roots = stored.IIDRoots
+	}
+	return &provisionerAWSModel{
+		Accounts:               stringListValue(stored.Accounts, aws.Accounts),
+		InstanceAge:            instanceAge,
+		DisableCustomSANs:      boolValueOrNull(stored.DisableCustomSANs, aws.DisableCustomSANs),
+		DisableTrustOnFirstUse: boolValueOrNull(stored.DisableTrustOnFirstUse, aws.DisableTrustOnFirstUse),
+		IIDRoots:               roots,
+	}
+}
+
+func gcpModelFromClient(stored *provisionerGCPModel, gcp *client.GCPProvisioner) *provisionerGCPModel {
+	if gcp == nil || (stored == nil && reflect.DeepEqual(*gcp, client.GCPProvisioner{})) {
+		return nil
+	}
+	if stored == nil {
+		stored = &provisionerGCPModel{}
+	}
+	return &provisionerGCPModel{
+		ServiceAccounts: stringListValue(stored.ServiceAccounts, gcp.ServiceAccounts),
+		ProjectIDs:      stringListValue(stored.ProjectIDs, gcp.ProjectIDs),
+	}
+}
+
+func azureModelFromClient(stored *provisionerAzureModel, azure *client.AzureProvisioner) *provisionerAzureModel {
+	if azure == nil {
+		return nil
+	}
+	if stored == nil {
+		stored = &provisionerAzureModel{}
+	}
+	return &provisionerAzureModel{
+		TenantID:        stringValueOrNull(azure.TenantID),
+		ResourceGroups:  stringListValue(stored.ResourceGroups, azure.ResourceGroups),
+		SubscriptionIDs: stringListValue(stored.SubscriptionIDs, azure.SubscriptionIDs),
+		ObjectIDs:       stringListValue(stored.ObjectIDs, azure.ObjectIDs),
+	}
+}
+
+// x5cModelFromClient converts an X5C configuration read from step-ca. Roots
+// holding the same certificates as the stored bundle keep its formatting, so
+// the output of data.stepca_ca_certificate can be used as is.
+func x5cModelFromClient(stored *provisionerX5CModel, x5c *client.X5CProvisioner) *provisionerX5CModel {
+	if x5c == nil {
+		return nil
+	}
+	var blocks []string
+	for _, root := range x5c.Roots {
+		blocks = append(blocks, string(root))
+	}
+	roots := stringValueOrNull(joinPEM(blocks))
+	if stored != nil && sameCertificates(stored.Roots.ValueString(), roots.ValueString()) {
+		roots = stored.Roots
+	}
+	return &provisionerX5CModel{Roots: roots}
+}
+
+// k8ssaModelFromClient converts a K8sSA configuration read from step-ca. Keys
+// equal to the stored ones keep their stored formatting.
+func k8ssaModelFromClient(stored *provisionerK8sSAModel, k8ssa *client.K8sSAProvisioner) *provisionerK8sSAModel {
+	if k8ssa == nil {
+		return nil
+	}
+	var storedKeys []string
+	if stored != nil {
+		storedKeys = listStrings(stored.PublicKeys)
+	}
+	keys := make([]string, 0, len(k8ssa.PublicKeys))
+	for i, key := range k8ssa.PublicKeys {
+		if i < len(storedKeys) && samePublicKeys(storedKeys[i], string(key)) {
+			keys = append(keys, storedKeys[i])
+			continue
+		}
+		keys = append(keys, string(key))
+	}
+	storedList := types.ListNull(types.StringType)
+	if stored != nil {
+		storedList = stored.PublicKeys
+	}
+	return &provisionerK8sSAModel{PublicKeys: stringListValue(storedList, keys)}
+}
+
+// nebulaModelFromClient converts a Nebula configuration read from step-ca.
+// Roots with the same PEM blocks as the stored bundle keep its formatting.
+func nebulaModelFromClient(stored *provisionerNebulaModel, nebula *client.NebulaProvisioner) *provisionerNebulaModel {
+	if nebula == nil {
+		return nil
+	}
+	var blocks []string
+	for _, root := range nebula.Roots {
+		blocks = append(blocks, string(root))
+	}
+	roots := stringValueOrNull(joinPEM(blocks))
+	if stored != nil && slices.Equal(splitPEM(stored.Roots.ValueString()), splitPEM(roots.ValueString())) {
+		roots = stored.Roots
+	}
+	return &provisionerNebulaModel{Roots: roots}
+}
+
+// scepModelFromClient converts a SCEP configuration read from step-ca. The
+// challenge and the decrypter key and password are write-only and not
+// compared; challenge_version and decrypter_key_version are kept.
+func scepModelFromClient(stored *provisionerSCEPModel, scep *client.SCEPProvisioner) *provisionerSCEPModel {
+	if scep == nil {
+		return nil
+	}
+	defaults := client.SCEPProvisioner{Challenge: scep.Challenge}
+	if stored == nil && reflect.DeepEqual(*scep, defaults) {
+		return nil
+	}
+	if stored == nil {
+		stored = &provisionerSCEPModel{}
+	}
+	m := &provisionerSCEPModel{
+		Challenge:                     types.StringNull(),
+		ChallengeVersion:              stored.ChallengeVersion,
+		Capabilities:                  stringListValue(stored.Capabilities, scep.Capabilities),
+		MinimumPublicKeyLength:        int64ValueOrNull(stored.MinimumPublicKeyLength, scep.MinimumPublicKeyLength),
+		IncludeRoot:                   boolValueOrNull(stored.IncludeRoot, scep.IncludeRoot),
+		EncryptionAlgorithmIdentifier: int64ValueOrNull(stored.EncryptionAlgorithmIdentifier, scep.EncryptionAlgorithmIdentifier),
+		DecrypterCertificate:          types.StringNull(),
+		DecrypterKey:                  types.StringNull(),
+		DecrypterKeyPassword:          types.StringNull(),
+		DecrypterKeyVersion:           stored.DecrypterKeyVersion,
+	}
+	if d := scep.Decrypter; d != nil {
+		m.DecrypterCertificate = stringValueOrNull(string(d.Certificate))
+		if sameCertificates(stored.DecrypterCertificate.ValueString(), m.DecrypterCertificate.ValueString()) {
+			m.DecrypterCertificate = stored.DecrypterCertificate
+		}
+	}
+	return m
+}
+
+// mapNames translates configuration names into admin API names. Names
+// without a translation are passed through for step-ca to reject.
+func mapNames(values []string, names map[string]string) []string {
+	var out []string
+	for _, v := range values {
+		if n, ok := names[v]; ok {
+			v = n
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// configNames is the inverse of mapNames.
+func configNames(values []string, names map[string]string) []string {
+	var out []string
+	for _, v := range values {
+		for name, apiName := range names {
+			if apiName == v {
+				v = name
+				break
+			}
+		}
+		out = append(out, v)
+	}
+	return out
+}
+
+// sameCertificates reports whether two PEM bundles hold the same
+// certificates, ignoring headers and whitespace.
+func sameCertificates(a, b string) bool {
+	ac, err := parseCertificates(a)
+	if err != nil {
+		return false
+	}
+	bc, err := parseCertificates(b)
+	if err != nil || len(ac) != len(bc) {
+		return false
+	}
+	for i := range ac {
+		if !bytes.Equal(ac[i].Raw, bc[i].Raw) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitPEM splits a PEM bundle into one PEM string per block.
+func splitPEM(bundle string) []string {
+	var out []string
+	rest := []byte(bundle)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return out
+		}
+		out = append(out, string(pem.EncodeToMemory(block)))
+	}
+}
+
+// pemBytes converts PEM strings for the admin API.
+func pemBytes(values []string) [][]byte {
+	var out [][]byte
+	for _, v := range values {
+		out = append(out, []byte(v))
+	}
+	return out
+}
+
+// parsePublicKeysPEM decodes every public key of a PEM bundle.
+func parsePublicKeysPEM(data string) ([]any, error) {
+	var keys []any
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		var key any
+		var err error
+		switch block.Type {
+		case "PUBLIC KEY":
+			key, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		case "CERTIFICATE":
+			var cert *x509.Certificate
+			if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+				key = cert.PublicKey
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no public keys found in PEM data")
+	}
+	return keys, nil
+}
+
+// samePublicKeys reports whether two PEM bundles hold the same public keys.
+func samePublicKeys(a, b string) bool {
+	ak, err := parsePublicKeysPEM(a)
+	if err != nil {
+		return false
+	}
+	bk, err := parsePublicKeysPEM(b)
+	if err != nil || len(ak) != len(bk) {
+		return false
+	}
+	for i := range ak {
+		if k, ok := ak[i].(interface{ Equal(crypto.PublicKey) bool }); !ok || !k.Equal(bk[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameDuration reports whether two duration strings, such as "1h" and
+// "60m", are equal.
+func sameDuration(a, b string) bool {
+	ad, err := time.ParseDuration(a)
+	if err != nil {
+		return false
+	}
+	bd, err := time.ParseDuration(b)
+	return err == nil && ad == bd
+}
+
+// int64ValueOrNull keeps an unset number null while step-ca reports the
+// default zero.
+func int64ValueOrNull(stored types.Int64, v int) types.Int64 {
+	if v == 0 && stored.IsNull() {
+		return types.Int64Null()
+	}
+	return types.Int64Value(int64(v))
+}
+
+// boolValueOrNull keeps an unset boolean null while step-ca reports the
+// default false.
+func boolValueOrNull(stored types.Bool, v bool) types.Bool {
+	if !v && stored.IsNull() {
+		return types.BoolNull()
+	}
+	return types.BoolValue(v)
+}
+
+// listStrings returns the known elements of a list of strings, or nil for a
+// null or unknown list.
+func listStrings(l types.List) []string {
+	if l.IsNull() || l.IsUnknown() {
+		return nil
+	}
+	var values []string
+	for _, v := range l.Elements() {
+		if s, ok := v.(types.String); ok && !s.IsNull() && !s.IsUnknown() {
+			values = append(values, s.ValueString())
+		}
+	}
+	return values
+}
+
+// stringListValue converts values read from step-ca into a list. step-ca
+// omits empty lists, so an empty result keeps a stored empty list rather than
+// turning it into null.
+func stringListValue(stored types.List, values []string) types.List {
+	if len(values) == 0 && (stored.IsNull() || stored.IsUnknown() || len(stored.Elements()) > 0) {
+		return types.ListNull(types.StringType)
+	}
+	elements := make([]attr.Value, 0, len(values))
+	for _, v := range values {
+		elements = append(elements, types.StringValue(v))
+	}
+	return types.ListValueMust(types.StringType, elements)
+}
+
+func jsonEquivalent(a, b string) bool {
+	var av, bv any
+	if json.Unmarshal([]byte(a), &av) != nil || json.Unmarshal([]byte(b), &bv) != nil {
+		return false
+	}
+	return reflect.DeepEqual(av, bv)
+}
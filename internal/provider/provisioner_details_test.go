@@ -0,0 +1,889 @@
+package provider
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-jose/go-jose/v3"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	pfresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/z0link/terraform-provider-stepca/internal/client"
+)
+
+const testJWKPublicKey = `{"kty":"EC","crv":"P-256","x":"x","y":"y","kid":"kid"}`
+
+// testRoot and testPublicKey hold a self-signed certificate and its key.
+var testRoot, testPublicKey = func() (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	tmpl := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "Test Root"}, IsCA: true, BasicConstraintsValid: true}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		panic(err)
+	}
+	pub, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		panic(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}))
+}()
+
+func TestValidateProvisionerDetails(t *testing.T) {
+	t.Parallel()
+
+	jwk := func(publicKey, encryptedKey, password types.String) *provisionerJWKModel {
+		return &provisionerJWKModel{PublicKey: publicKey, EncryptedKey: encryptedKey, Password: password}
+	}
+	null := types.StringNull()
+	oidc := func(clientID, endpoint types.String) *provisionerOIDCModel {
+		return &provisionerOIDCModel{ClientID: clientID, ConfigurationEndpoint: endpoint}
+	}
+	endpoint := types.StringValue("https://idp.example.com/.well-known/openid-configuration")
+
+	tests := []struct {
+		name    string
+		data    provisionerResourceModel
+		wantErr bool
+	}{
+		{
+			name: "generated key",
+			data: provisionerResourceModel{Type: types.StringValue("JWK"), JWK: jwk(null, null, types.StringValue("secret"))},
+		},
+		{
+			name: "existing key",
+			data: provisionerResourceModel{Type: types.StringValue("JWK"), JWK: jwk(types.StringValue(testJWKPublicKey), types.StringValue("jwe"), null)},
+		},
+		{
+			name: "public key only",
+			data: provisionerResourceModel{Type: types.StringValue("JWK"), JWK: jwk(types.StringValue(testJWKPublicKey), null, null)},
+		},
+		{
+			name: "unknown password",
+			data: provisionerResourceModel{Type: types.StringValue("JWK"), JWK: jwk(null, null, types.StringUnknown())},
+		},
+		{
+			name: "other type without block",
+			data: provisionerResourceModel{Type: types.StringValue("ACME")},
+		},
+		{
+			name:    "jwk without block",
+			data:    provisionerResourceModel{Type: types.StringValue("JWK")},
+			wantErr: true,
+		},
+		{
+			name:    "block for other type",
+			data:    provisionerResourceModel{Type: types.StringValue("ACME"), JWK: jwk(null, null, types.StringValue("secret"))},
+			wantErr: true,
+		},
+		{
+			name:    "neither key nor password",
+			data:    provisionerResourceModel{Type: types.StringValue("JWK"), JWK: jwk(null, null, null)},
+			wantErr: true,
+		},
+		{
+			name:    "encrypted key without public key",
+			data:    provisionerResourceModel{Type: types.StringValue("JWK"), JWK: jwk(null, types.StringValue("jwe"), types.StringValue("secret"))},
+			wantErr: true,
+		},
+		{
+			name:    "password with public key",
+			data:    provisionerResourceModel{Type: types.StringValue("JWK"), JWK: jwk(types.StringValue(testJWKPublicKey), null, types.StringValue("secret"))},
+			wantErr: true,
+		},
+		{
+			name: "password version with public key",
+			data: provisionerResourceModel{Type: types.StringValue("JWK"), JWK: &provisionerJWKModel{
+				PublicKey: types.StringValue(testJWKPublicKey), EncryptedKey: null, Password: null, PasswordVersion: types.Int64Value(2),
+			}},
+			wantErr: true,
+		},
+		{
+			name:    "malformed public key",
+			data:    provisionerResourceModel{Type: types.StringValue("JWK"), JWK: jwk(types.StringValue("-----BEGIN PUBLIC KEY-----"), null, null)},
+			wantErr: true,
+		},
+		{
+			name: "oidc",
+			data: provisionerResourceModel{Type: types.StringValue("OIDC"), OIDC: oidc(types.StringValue("step"), endpoint)},
+		},
+		{
+			name: "oidc with unknown endpoint",
+			data: provisionerResourceModel{Type: types.StringValue("OIDC"), OIDC: oidc(types.StringValue("step"), types.StringUnknown())},
+		},
+		{
+			name:    "oidc without block",
+			data:    provisionerResourceModel{Type: types.StringValue("OIDC")},
+			wantErr: true,
+		},
+		{
+			name:    "oidc without client id",
+			data:    provisionerResourceModel{Type: types.StringValue("OIDC"), OIDC: oidc(null, endpoint)},
+			wantErr: true,
+		},
+		{
+			name:    "oidc without endpoint",
+			data:    provisionerResourceModel{Type: types.StringValue("OIDC"), OIDC: oidc(types.StringValue("step"), null)},
+			wantErr: true,
+		},
+		{
+			name:    "oidc endpoint not a URL",
+			data:    provisionerResourceModel{Type: types.StringValue("OIDC"), OIDC: oidc(types.StringValue("step"), types.StringValue("idp.example.com"))},
+			wantErr: true,
+		},
+		{
+			name: "acme",
+			data: provisionerResourceModel{Type: types.StringValue("ACME"), ACME: &provisionerACMEModel{
+				Challenges:         stringListValue(types.ListNull(types.StringType), []string{"dns-01", "device-attest-01"}),
+				AttestationFormats: stringListValue(types.ListNull(types.StringType), []string{"apple", "tpm"}),
+			}},
+		},
+		{
+			name: "acme with defaults",
+			data: provisionerResourceModel{Type: types.StringValue("ACME"), ACME: &provisionerACMEModel{RequireEAB: types.BoolValue(true)}},
+		},
+		{
+			name: "acme unknown challenge",
+			data: provisionerResourceModel{Type: types.StringValue("ACME"), ACME: &provisionerACMEModel{
+				Challenges: stringListValue(types.ListNull(types.StringType), []string{"http-01", "HTTP_01"}),
+			}},
+			wantErr: true,
+		},
+		{
+			name: "acme unknown attestation format",
+			data: provisionerResourceModel{Type: types.StringValue("ACME"), ACME: &provisionerACMEModel{
+				Challenges:         stringListValue(types.ListNull(types.StringType), []string{"device-attest-01"}),
+				AttestationFormats: stringListValue(types.ListNull(types.StringType), []string{"android"}),
+			}},
+			wantErr: true,
+		},
+		{
+			name: "acme attestation without device-attest-01",
+			data: provisionerResourceModel{Type: types.StringValue("ACME"), ACME: &provisionerACMEModel{
+				AttestationFormats: stringListValue(types.ListNull(types.StringType), []string{"apple"}),
+			}},
+			wantErr: true,
+		},
+		{
+			name: "acme malformed attestation roots",
+			data: provisionerResourceModel{Type: types.StringValue("ACME"), ACME: &provisionerACMEModel{
+				Challenges:       stringListValue(types.ListNull(types.StringType), []string{"device-attest-01"}),
+				AttestationRoots: types.StringValue("not a certificate"),
+			}},
+			wantErr: true,
+		},
+		{
+			name: "aws",
+			data: provisionerResourceModel{Type: types.StringValue("AWS"), AWS: &provisionerAWSModel{InstanceAge: types.StringValue("1h30m")}},
+		},
+		{
+			name: "aws without block",
+			data: provisionerResourceModel{Type: types.StringValue("AWS")},
+		},
+		{
+			name:    "aws invalid instance age",
+			data:    provisionerResourceModel{Type: types.StringValue("AWS"), AWS: &provisionerAWSModel{InstanceAge: types.StringValue("1 hour")}},
+			wantErr: true,
+		},
+		{
+			name:    "aws malformed iid roots",
+			data:    provisionerResourceModel{Type: types.StringValue("AWS"), AWS: &provisionerAWSModel{IIDRoots: types.StringValue("roots.pem")}},
+			wantErr: true,
+		},
+		{
+			name:    "gcp block for aws",
+			data:    provisionerResourceModel{Type: types.StringValue("AWS"), GCP: &provisionerGCPModel{}},
+			wantErr: true,
+		},
+		{
+			name: "gcp",
+			data: provisionerResourceModel{Type: types.StringValue("GCP"), GCP: &provisionerGCPModel{
+				ProjectIDs: stringListValue(types.ListNull(types.StringType), []string{"project"}),
+			}},
+		},
+		{
+			name: "azure",
+			data: provisionerResourceModel{Type: types.StringValue("Azure"), Azure: &provisionerAzureModel{TenantID: types.StringValue("tenant")}},
+		},
+		{
+			name:    "azure without block",
+			data:    provisionerResourceModel{Type: types.StringValue("Azure")},
+			wantErr: true,
+		},
+		{
+			name:    "azure without tenant",
+			data:    provisionerResourceModel{Type: types.StringValue("Azure"), Azure: &provisionerAzureModel{TenantID: null}},
+			wantErr: true,
+		},
+		{
+			name:    "azure block for gcp",
+			data:    provisionerResourceModel{Type: types.StringValue("GCP"), Azure: &provisionerAzureModel{TenantID: types.StringValue("tenant")}},
+			wantErr: true,
+		},
+		{
+			name: "x5c",
+			data: provisionerResourceModel{Type: types.StringValue("X5C"), X5C: &provisionerX5CModel{Roots: types.StringValue(testRoot)}},
+		},
+		{
+			name: "x5c with unknown roots",
+			data: provisionerResourceModel{Type: types.StringValue("X5C"), X5C: &provisionerX5CModel{Roots: types.StringUnknown()}},
+		},
+		{
+			name:    "x5c without block",
+			data:    provisionerResourceModel{Type: types.StringValue("X5C")},
+			wantErr: true,
+		},
+		{
+			name:    "x5c without roots",
+			data:    provisionerResourceModel{Type: types.StringValue("X5C"), X5C: &provisionerX5CModel{Roots: null}},
+			wantErr: true,
+		},
+		{
+			name:    "x5c malformed roots",
+			data:    provisionerResourceModel{Type: types.StringValue("X5C"), X5C: &provisionerX5CModel{Roots: types.StringValue(testPublicKey)}},
+			wantErr: true,
+		},
+		{
+			name: "k8ssa",
+			data: provisionerResourceModel{Type: types.StringValue("K8sSA"), K8sSA: &provisionerK8sSAModel{
+				PublicKeys: stringListValue(types.ListNull(types.StringType), []string{testPublicKey}),
+			}},
+		},
+		{
+			name:    "k8ssa without keys",
+			data:    provisionerResourceModel{Type: types.StringValue("K8sSA"), K8sSA: &provisionerK8sSAModel{PublicKeys: types.ListNull(types.StringType)}},
+			wantErr: true,
+		},
+		{
+			name: "k8ssa malformed key",
+			data: provisionerResourceModel{Type: types.StringValue("K8sSA"), K8sSA: &provisionerK8sSAModel{
+				PublicKeys: stringListValue(types.ListNull(types.StringType), []string{"ssh-ed25519 AAAA"}),
+			}},
+			wantErr: true,
+		},
+		{
+			name: "sshpop",
+			data: provisionerResourceModel{Type: types.StringValue("SSHPOP"), SSHPOP: &provisionerSSHPOPModel{}},
+		},
+		{
+			name:    "sshpop without block",
+			data:    provisionerResourceModel{Type: types.StringValue("SSHPOP")},
+			wantErr: true,
+		},
+		{
+			name:    "sshpop block for x5c",
+			data:    provisionerResourceModel{Type: types.StringValue("X5C"), X5C: &provisionerX5CModel{Roots: types.StringValue(testRoot)}, SSHPOP: &provisionerSSHPOPModel{}},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if diags := validateProvisionerDetails(tc.data); diags.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%t got %v", tc.wantErr, diags)
+			}
+		})
+	}
+}
+
+func TestProvisionerResourceCreateGeneratesJWK(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fake := &fakeProvisionerClient{getResp: &client.Provisioner{ID: "id", Name: "ci", Type: "JWK"}}
+	r := &provisionerResource{client: fake}
+	var schemaResp pfresource.SchemaResponse
+	r.Schema(ctx, pfresource.SchemaRequest{}, &schemaResp)
+
+	model := provisionerResourceModel{
+		ID:                  types.StringUnknown(),
+		Name:                types.StringValue("ci"),
+		Type:                types.StringValue("JWK"),
+		Admin:               types.BoolNull(),
+		X509Template:        types.StringNull(),
+		SSHTemplate:         types.StringNull(),
+		AttestationTemplate: types.StringNull(),
+		JWK: &provisionerJWKModel{
+			PublicKey:    types.StringUnknown(),
+			EncryptedKey: types.StringUnknown(),
+			Password:     types.StringNull(),
+		},
+	}
+	plan := tfsdk.Plan{Schema: schemaResp.Schema}
+	plan.Set(ctx, &model)
+	model.JWK = &provisionerJWKModel{PublicKey: types.StringNull(), EncryptedKey: types.StringNull(), Password: types.StringValue("secret")}
+	model.ID = types.StringNull()
+	configured := tfsdk.Plan{Schema: schemaResp.Schema}
+	configured.Set(ctx, &model)
+
+	resp := pfresource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	r.Create(ctx, pfresource.CreateRequest{Plan: plan, Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: configured.Raw}}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("create: %v", resp.Diagnostics)
+	}
+
+	sent := fake.createInput.Details
+	if sent == nil || sent.JWK == nil {
+		t.Fatalf("expected JWK details in the request, got %#v", fake.createInput)
+	}
+	var jwk map[string]string
+	if err := json.Unmarshal(sent.JWK.PublicKey, &jwk); err != nil || jwk["kty"] != "EC" || jwk["d"] != "" {
+		t.Fatalf("unexpected public key %s", sent.JWK.PublicKey)
+	}
+	if strings.Count(string(sent.JWK.EncryptedPrivateKey), ".") != 4 {
+		t.Fatalf("expected a compact JWE, got %s", sent.JWK.EncryptedPrivateKey)
+	}
+
+	var got provisionerResourceModel
+	resp.State.Get(ctx, &got)
+	if got.JWK == nil || got.JWK.PublicKey.ValueString() != string(sent.JWK.PublicKey) || !got.JWK.Password.IsNull() {
+		t.Fatalf("unexpected state: %#v", got.JWK)
+	}
+}
+
+func TestProvisionerResourceUpdateRotatesJWK(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	r := &provisionerResource{}
+	var schemaResp pfresource.SchemaResponse
+	r.Schema(ctx, pfresource.SchemaRequest{}, &schemaResp)
+	generated, err := client.GenerateJWKProvisionerKey("old")
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	model := func(publicKey, encryptedKey types.String, version int64) provisionerResourceModel {
+		return provisionerResourceModel{
+			ID:                  types.StringValue("id"),
+			Name:                types.StringValue("ci"),
+			Type:                types.StringValue("JWK"),
+			Admin:               types.BoolNull(),
+			X509Template:        types.StringNull(),
+			SSHTemplate:         types.StringNull(),
+			AttestationTemplate: types.StringNull(),
+			JWK: &provisionerJWKModel{
+				PublicKey:       publicKey,
+				EncryptedKey:    encryptedKey,
+				Password:        types.StringNull(),
+				PasswordVersion: types.Int64Value(version),
+			},
+		}
+	}
+	stateModel := model(types.StringValue(generated.PublicKey), types.StringValue(generated.EncryptedKey), 1)
+	state := tfsdk.State{Schema: schemaResp.Schema}
+	state.Set(ctx, &stateModel)
+
+	tests := []struct {
+		name       string
+		version    int64
+		wantRotate bool
+	}{
+		{name: "same version", version: 1},
+		{name: "new version", version: 2, wantRotate: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			planModel := model(types.StringUnknown(), types.StringUnknown(), tc.version)
+			plan := tfsdk.Plan{Schema: schemaResp.Schema}
+			plan.Set(ctx, &planModel)
+			configModel := model(types.StringNull(), types.StringNull(), tc.version)
+			configModel.ID = types.StringNull()
+			configModel.JWK.Password = types.StringValue("new")
+			configured := tfsdk.Plan{Schema: schemaResp.Schema}
+			configured.Set(ctx, &configModel)
+			config := tfsdk.Config{Schema: schemaResp.Schema, Raw: configured.Raw}
+
+			block := path.Root("jwk")
+			for name, value := range map[string]*types.String{"public_key": &planModel.JWK.PublicKey, "encrypted_key": &planModel.JWK.EncryptedKey} {
+				req := planmodifier.StringRequest{Path: block.AtName(name), Config: config, ConfigValue: types.StringNull(),
+					Plan: plan, PlanValue: *value, State: state}
+				state.GetAttribute(ctx, block.AtName(name), &req.StateValue)
+				resp := &planmodifier.StringResponse{PlanValue: req.PlanValue}
+				for _, m := range schemaResp.Schema.Blocks["jwk"].(schema.SingleNestedBlock).Attributes[name].(schema.StringAttribute).PlanModifiers {
+					m.PlanModifyString(ctx, req, resp)
+				}
+				if resp.PlanValue.IsUnknown() != tc.wantRotate {
+					t.Fatalf("%s: expected unknown=%t, got %s", name, tc.wantRotate, resp.PlanValue)
+				}
+				*value = resp.PlanValue
+			}
+
+			if diags := prepareDetails(ctx, config, &planModel); diags.HasError() {
+				t.Fatalf("prepare: %v", diags)
+			}
+			fake := &fakeProvisionerClient{getResp: &client.Provisioner{ID: "id", Name: "ci", Type: "JWK"}}
+			r.client = fake
+			if _, diags := r.updateProvisioner(ctx, &stateModel, &planModel); diags.HasError() {
+				t.Fatalf("update: %v", diags)
+			}
+			if fake.replaceCalled != tc.wantRotate {
+				t.Fatalf("expected replace=%t got %t", tc.wantRotate, fake.replaceCalled)
+			}
+			if !tc.wantRotate {
+				return
+			}
+			sent := fake.replaceInput.Details.JWK
+			if string(sent.PublicKey) == generated.PublicKey {
+				t.Fatalf("expected a new key pair")
+			}
+			jwe, err := jose.ParseEncrypted(string(sent.EncryptedPrivateKey))
+			if err != nil {
+				t.Fatalf("expected a compact JWE: %v", err)
+			}
+			if _, err := jwe.Decrypt([]byte("new")); err != nil {
+				t.Fatalf("the new key must be encrypted with the new password: %v", err)
+			}
+		})
+	}
+}
+
+func TestProvisionerSetDetails(t *testing.T) {
+	t.Parallel()
+
+	stored := `{"kty": "EC", "crv": "P-256", "x": "x", "y": "y", "kid": "kid"}`
+	data := provisionerResourceModel{JWK: &provisionerJWKModel{PublicKey: types.StringValue(stored)}}
+	data.setDetails(&client.ProvisionerDetails{JWK: &client.JWKProvisioner{PublicKey: []byte(testJWKPublicKey)}})
+	if data.JWK.PublicKey.ValueString() != stored || !data.JWK.EncryptedKey.IsNull() {
+		t.Fatalf("an equivalent public key must keep its stored form: %#v", data.JWK)
+	}
+
+	data.setDetails(&client.ProvisionerDetails{JWK: &client.JWKProvisioner{PublicKey: []byte(`{"kty":"OKP"}`)}})
+	if data.JWK.PublicKey.ValueString() != `{"kty":"OKP"}` {
+		t.Fatalf("a changed public key must be reported: %#v", data.JWK)
+	}
+
+	data.setDetails(nil)
+	if data.JWK != nil {
+		t.Fatalf("expected no jwk block without details")
+	}
+}
+
+func TestProvisionerSetOIDCDetails(t *testing.T) {
+	t.Parallel()
+
+	remote := &client.OIDCProvisioner{
+		ClientID:              "step",
+		ClientSecret:          "s3cret",
+		ConfigurationEndpoint: "https://idp.example.com/.well-known/openid-configuration",
+		Domains:               []string{"example.com"},
+		TenantID:              "tenant",
+	}
+	data := provisionerResourceModel{OIDC: &provisionerOIDCModel{
+		Admins: types.ListValueMust(types.StringType, []attr.Value{}),
+		Groups: types.ListNull(types.StringType),
+	}}
+	data.setDetails(&client.ProvisionerDetails{OIDC: remote})
+	if data.JWK != nil || data.OIDC == nil {
+		t.Fatalf("unexpected blocks: %#v", data)
+	}
+	if data.OIDC.Admins.IsNull() || len(data.OIDC.Admins.Elements()) != 0 || !data.OIDC.Groups.IsNull() || !data.OIDC.ListenAddress.IsNull() {
+		t.Fatalf("unset lists must keep their configured form: %#v", data.OIDC)
+	}
+
+	// Converting back must give step-ca's configuration, so that a change on
+	// either side shows up as a difference.
+	if got := provisionerDetailsToClient(data); got == nil || !reflect.DeepEqual(got.OIDC, remote) {
+		t.Fatalf("round trip changed the details: %#v", got)
+	}
+
+	changed := *remote
+	changed.Groups = []string{"engineering"}
+	data.setDetails(&client.ProvisionerDetails{OIDC: &changed})
+	if got := listStrings(data.OIDC.Groups); !reflect.DeepEqual(got, []string{"engineering"}) {
+		t.Fatalf("expected remote groups, got %v", got)
+	}
+}
+
+func TestProvisionerSetACMEDetails(t *testing.T) {
+	t.Parallel()
+
+	// step-ca reports the defaults for an ACME provisioner created without
+	// an acme block.
+	var data provisionerResourceModel
+	data.setDetails(&client.ProvisionerDetails{ACME: &client.ACMEProvisioner{}})
+	if data.ACME != nil {
+		t.Fatalf("expected no acme block for the default configuration, got %#v", data.ACME)
+	}
+
+	_, root := testCertificateChain(t, "attestation")
+	remote := &client.ACMEProvisioner{
+		RequireEAB:         true,
+		Challenges:         []string{"DNS_01", "DEVICE_ATTEST_01"},
+		AttestationFormats: []string{"APPLE"},
+		AttestationRoots:   []byte(root),
+	}
+	data.ACME = &provisionerACMEModel{
+		ForceCN:          types.BoolNull(),
+		AttestationRoots: types.StringValue("# Apple root\n" + root),
+	}
+	data.setDetails(&client.ProvisionerDetails{ACME: remote})
+	if data.ACME == nil || !data.ACME.ForceCN.IsNull() || !data.ACME.RequireEAB.ValueBool() {
+		t.Fatalf("unexpected acme block: %#v", data.ACME)
+	}
+	if got := listStrings(data.ACME.Challenges); !reflect.DeepEqual(got, []string{"dns-01", "device-attest-01"}) {
+		t.Fatalf("unexpected challenges %v", got)
+	}
+	if !strings.HasPrefix(data.ACME.AttestationRoots.ValueString(), "# Apple root") {
+		t.Fatalf("equivalent attestation roots must keep their stored form")
+	}
+	if got := provisionerDetailsToClient(data); got == nil || !reflect.DeepEqual(got.ACME.Challenges, remote.Challenges) || !reflect.DeepEqual(got.ACME.AttestationFormats, remote.AttestationFormats) {
+		t.Fatalf("unexpected admin API details: %#v", got)
+	}
+
+	data.setDetails(&client.ProvisionerDetails{ACME: &client.ACMEProvisioner{}})
+	if data.ACME == nil || data.ACME.RequireEAB.ValueBool() || !data.ACME.Challenges.IsNull() || !data.ACME.AttestationRoots.IsNull() {
+		t.Fatalf("settings removed in step-ca must show up as drift: %#v", data.ACME)
+	}
+}
+
+func TestProvisionerResourceReadCloudDetails(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	_, root := testCertificateChain(t, "aws")
+	fake := &fakeProvisionerClient{getResp: &client.Provisioner{ID: "id", Name: "aws", Type: "AWS", Details: &client.ProvisionerDetails{
+		AWS: &client.AWSProvisioner{Accounts: []string{"123456789012"}, InstanceAge: "60m", IIDRoots: []string{root}},
+	}}}
+	r := &provisionerResource{client: fake}
+	var schemaResp pfresource.SchemaResponse
+	r.Schema(ctx, pfresource.SchemaRequest{}, &schemaResp)
+
+	model := provisionerResourceModel{
+		ID:                  types.StringValue("id"),
+		Name:                types.StringValue("aws"),
+		Type:                types.StringValue("AWS"),
+		Admin:               types.BoolValue(false),
+		X509Template:        types.StringNull(),
+		SSHTemplate:         types.StringNull(),
+		AttestationTemplate: types.StringNull(),
+		AWS: &provisionerAWSModel{
+			Accounts:               stringListValue(types.ListNull(types.StringType), []string{"123456789012", "210987654321"}),
+			InstanceAge:            types.StringValue("1h"),
+			DisableCustomSANs:      types.BoolNull(),
+			DisableTrustOnFirstUse: types.BoolValue(false),
+			IIDRoots:               types.StringValue(root),
+		},
+	}
+	state := tfsdk.State{Schema: schemaResp.Schema}
+	state.Set(ctx, &model)
+	resp := pfresource.ReadResponse{State: state}
+	r.Read(ctx, pfresource.ReadRequest{State: state}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("read: %v", resp.Diagnostics)
+	}
+
+	var got provisionerResourceModel
+	resp.State.Get(ctx, &got)
+	if got.AWS == nil || got.GCP != nil || got.Azure != nil {
+		t.Fatalf("unexpected blocks: %#v", got)
+	}
+	if accounts := listStrings(got.AWS.Accounts); !reflect.DeepEqual(accounts, []string{"123456789012"}) {
+		t.Fatalf("expected the account removed in step-ca to show up as drift, got %v", accounts)
+	}
+	if got.AWS.InstanceAge.ValueString() != "1h" || got.AWS.IIDRoots.ValueString() != root {
+		t.Fatalf("equivalent values must keep their configured form: %#v", got.AWS)
+	}
+	if !got.AWS.DisableCustomSANs.IsNull() || got.AWS.DisableTrustOnFirstUse.IsNull() {
+		t.Fatalf("unexpected flags: %#v", got.AWS)
+	}
+}
+
+func TestProvisionerResourceUpdateCloudDetails(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeProvisionerClient{getResp: &client.Provisioner{Name: "gcp", Type: "GCP"}}
+	r := &provisionerResource{client: fake}
+	projects := func(ids ...string) *provisionerGCPModel {
+		return &provisionerGCPModel{
+			ServiceAccounts: types.ListNull(types.StringType),
+			ProjectIDs:      stringListValue(types.ListNull(types.StringType), ids),
+		}
+	}
+	state := provisionerResourceModel{Name: types.StringValue("gcp"), Type: types.StringValue("GCP"), GCP: projects("prod")}
+	plan := provisionerResourceModel{Name: types.StringValue("gcp"), Type: types.StringValue("GCP"), GCP: projects("prod", "staging")}
+	updated, diags := r.updateProvisioner(context.Background(), &state, &plan)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %#v", diags)
+	}
+	if !fake.replaceCalled || fake.replaceInput.Details == nil || !reflect.DeepEqual(fake.replaceInput.Details.GCP.ProjectIDs, []string{"prod", "staging"}) {
+		t.Fatalf("unexpected replace payload: %#v", fake.replaceInput)
+	}
+	if updated == nil || updated.GCP != plan.GCP {
+		t.Fatalf("unexpected updated state: %#v", updated)
+	}
+}
+
+func TestProvisionerSetX5CK8sSADetails(t *testing.T) {
+	t.Parallel()
+
+	// The root endpoint returns the certificate without a trailing newline
+	// in some versions; the stored form must survive the round trip.
+	configured := strings.TrimSuffix(testRoot, "\n")
+	data := provisionerResourceModel{
+		X5C:    &provisionerX5CModel{Roots: types.StringValue(configured)},
+		SSHPOP: &provisionerSSHPOPModel{},
+	}
+	sent := provisionerDetailsToClient(data)
+	if sent == nil || len(sent.X5C.Roots) != 1 || sent.SSHPOP == nil {
+		t.Fatalf("unexpected admin API details: %#v", sent)
+	}
+	data.setDetails(sent)
+	if data.X5C.Roots.ValueString() != configured || data.SSHPOP == nil {
+		t.Fatalf("equivalent roots must keep their configured form: %#v", data.X5C)
+	}
+
+	_, other := testCertificateChain(t, "other")
+	data.setDetails(&client.ProvisionerDetails{X5C: &client.X5CProvisioner{Roots: [][]byte{[]byte(testRoot), []byte(other)}}})
+	if data.X5C.Roots.ValueString() != testRoot+other || data.SSHPOP != nil {
+		t.Fatalf("an added root must show up as drift: %#v", data)
+	}
+
+	// An imported SSHPOP provisioner gets its block back.
+	data = provisionerResourceModel{}
+	data.setDetails(&client.ProvisionerDetails{SSHPOP: &client.SSHPOPProvisioner{}})
+	if data.SSHPOP == nil {
+		t.Fatalf("expected an sshpop block: %#v", data)
+	}
+
+	reformatted := "sa.pub\n" + testPublicKey
+	data = provisionerResourceModel{K8sSA: &provisionerK8sSAModel{
+		PublicKeys: stringListValue(types.ListNull(types.StringType), []string{reformatted}),
+	}}
+	data.setDetails(&client.ProvisionerDetails{K8sSA: &client.K8sSAProvisioner{PublicKeys: [][]byte{[]byte(testPublicKey)}}})
+	if got := listStrings(data.K8sSA.PublicKeys); !reflect.DeepEqual(got, []string{reformatted}) {
+		t.Fatalf("equivalent keys must keep their configured form: %q", got)
+	}
+	data.setDetails(&client.ProvisionerDetails{K8sSA: &client.K8sSAProvisioner{PublicKeys: [][]byte{[]byte(other)}}})
+	if got := listStrings(data.K8sSA.PublicKeys); !reflect.DeepEqual(got, []string{other}) {
+		t.Fatalf("a replaced key must show up as drift: %q", got)
+	}
+}
+
+func TestValidateProvisionerSCEP(t *testing.T) {
+	t.Parallel()
+
+	cert, key := testDecrypter(t)
+	_, otherKey := testDecrypter(t)
+	scep := func(f func(*provisionerSCEPModel)) provisionerResourceModel {
+		m := &provisionerSCEPModel{
+			MinimumPublicKeyLength:        types.Int64Value(2048),
+			EncryptionAlgorithmIdentifier: types.Int64Value(2),
+			DecrypterCertificate:          types.StringValue(cert),
+			DecrypterKey:                  types.StringValue(key),
+			DecrypterKeyPassword:          types.StringNull(),
+		}
+		if f != nil {
+			f(m)
+		}
+		return provisionerResourceModel{Type: types.StringValue("SCEP"), SCEP: m}
+	}
+	encrypted := string(pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: []byte("ciphertext")}))
+
+	tests := []struct {
+		name    string
+		data    provisionerResourceModel
+		wantErr bool
+	}{
+		{name: "decrypter", data: scep(nil)},
+		{name: "no decrypter", data: scep(func(m *provisionerSCEPModel) {
+			m.DecrypterCertificate, m.DecrypterKey = types.StringNull(), types.StringNull()
+		})},
+		{name: "encrypted key", data: scep(func(m *provisionerSCEPModel) {
+			m.DecrypterKey, m.DecrypterKeyPassword = types.StringValue(encrypted), types.StringValue("secret")
+		})},
+		{name: "unknown key", data: scep(func(m *provisionerSCEPModel) { m.DecrypterKey = types.StringUnknown() })},
+		{name: "key shorter than minimum", wantErr: true, data: scep(func(m *provisionerSCEPModel) {
+			m.MinimumPublicKeyLength = types.Int64Value(3072)
+		})},
+		{name: "key of another certificate", wantErr: true, data: scep(func(m *provisionerSCEPModel) {
+			m.DecrypterKey = types.StringValue(otherKey)
+		})},
+		{name: "certificate without key", wantErr: true, data: scep(func(m *provisionerSCEPModel) { m.DecrypterKey = types.StringNull() })},
+		{name: "encrypted key without password", wantErr: true, data: scep(func(m *provisionerSCEPModel) {
+			m.DecrypterKey = types.StringValue(encrypted)
+		})},
+		{name: "password without key", wantErr: true, data: scep(func(m *provisionerSCEPModel) {
+			m.DecrypterCertificate, m.DecrypterKey = types.StringNull(), types.StringNull()
+			m.DecrypterKeyPassword = types.StringValue("secret")
+		})},
+		{name: "non RSA decrypter", wantErr: true, data: scep(func(m *provisionerSCEPModel) {
+			m.DecrypterCertificate = types.StringValue(testRoot)
+		})},
+		{name: "unknown encryption algorithm", wantErr: true, data: scep(func(m *provisionerSCEPModel) {
+			m.EncryptionAlgorithmIdentifier = types.Int64Value(5)
+		})},
+		{name: "scep block for acme", wantErr: true, data: func() provisionerResourceModel {
+			data := scep(nil)
+			data.Type = types.StringValue("ACME")
+			return data
+		}()},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if diags := validateProvisionerDetails(tc.data); diags.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%t got %v", tc.wantErr, diags)
+			}
+		})
+	}
+}
+
+func TestProvisionerResourceCreateSendsSCEPSecrets(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cert, key := testDecrypter(t)
+	fake := &fakeProvisionerClient{getResp: &client.Provisioner{ID: "id", Name: "mdm", Type: "SCEP"}}
+	r := &provisionerResource{client: fake}
+	var schemaResp pfresource.SchemaResponse
+	r.Schema(ctx, pfresource.SchemaRequest{}, &schemaResp)
+
+	model := provisionerResourceModel{
+		ID:                  types.StringUnknown(),
+		Name:                types.StringValue("mdm"),
+		Type:                types.StringValue("SCEP"),
+		Admin:               types.BoolNull(),
+		X509Template:        types.StringNull(),
+		SSHTemplate:         types.StringNull(),
+		AttestationTemplate: types.StringNull(),
+		SCEP: &provisionerSCEPModel{
+			Challenge:                     types.StringNull(),
+			ChallengeVersion:              types.Int64Value(1),
+			Capabilities:                  types.ListNull(types.StringType),
+			MinimumPublicKeyLength:        types.Int64Null(),
+			IncludeRoot:                   types.BoolValue(true),
+			EncryptionAlgorithmIdentifier: types.Int64Null(),
+			DecrypterCertificate:          types.StringValue(cert),
+			DecrypterKey:                  types.StringNull(),
+			DecrypterKeyPassword:          types.StringNull(),
+			DecrypterKeyVersion:           types.Int64Null(),
+		},
+	}
+	plan := tfsdk.Plan{Schema: schemaResp.Schema}
+	plan.Set(ctx, &model)
+	model.SCEP.Challenge = types.StringValue("s3cret")
+	model.SCEP.DecrypterKey = types.StringValue(key)
+	model.SCEP.DecrypterKeyPassword = types.StringValue("pw")
+	configured := tfsdk.Plan{Schema: schemaResp.Schema}
+	configured.Set(ctx, &model)
+
+	resp := pfresource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	r.Create(ctx, pfresource.CreateRequest{Plan: plan, Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: configured.Raw}}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("create: %v", resp.Diagnostics)
+	}
+	sent := fake.createInput.Details
+	if sent == nil || sent.SCEP == nil || sent.SCEP.Challenge != "s3cret" || !sent.SCEP.IncludeRoot {
+		t.Fatalf("unexpected request: %#v", fake.createInput.Details)
+	}
+	if d := sent.SCEP.Decrypter; d == nil || string(d.Certificate) != cert || string(d.Key) != key || d.KeyPassword != "pw" {
+		t.Fatalf("unexpected decrypter: %#v", sent.SCEP.Decrypter)
+	}
+
+	var got provisionerResourceModel
+	resp.State.Get(ctx, &got)
+	if got.SCEP == nil || !got.SCEP.Challenge.IsNull() || got.SCEP.ChallengeVersion.ValueInt64() != 1 ||
+		!got.SCEP.DecrypterKey.IsNull() || !got.SCEP.DecrypterKeyPassword.IsNull() {
+		t.Fatalf("write-only values must not be stored: %#v", got.SCEP)
+	}
+
+	// Read ignores the challenge and decrypter key step-ca reports, and keeps
+	// the default configuration of a provisioner without a scep block absent.
+	got.setDetails(&client.ProvisionerDetails{SCEP: &client.SCEPProvisioner{
+		Challenge:   "s3cret",
+		IncludeRoot: true,
+		Decrypter:   &client.SCEPDecrypter{Certificate: []byte(cert), Key: []byte(key), KeyPassword: "pw"},
+	}})
+	if got.SCEP == nil || !got.SCEP.Challenge.IsNull() || got.SCEP.ChallengeVersion.ValueInt64() != 1 ||
+		got.SCEP.DecrypterCertificate.ValueString() != cert || !got.SCEP.DecrypterKey.IsNull() || !got.SCEP.DecrypterKeyPassword.IsNull() {
+		t.Fatalf("unexpected scep block after read: %#v", got.SCEP)
+	}
+	var unset provisionerResourceModel
+	unset.setDetails(&client.ProvisionerDetails{SCEP: &client.SCEPProvisioner{Challenge: "s3cret"}})
+	if unset.SCEP != nil {
+		t.Fatalf("expected no scep block for the default configuration")
+	}
+}
+
+func TestProvisionerResourceUpdateSCEPSecrets(t *testing.T) {
+	t.Parallel()
+
+	cert, key := testDecrypter(t)
+	scep := func() *provisionerSCEPModel {
+		return &provisionerSCEPModel{
+			ChallengeVersion:     types.Int64Value(1),
+			Capabilities:         types.ListNull(types.StringType),
+			IncludeRoot:          types.BoolValue(true),
+			DecrypterCertificate: types.StringValue(cert),
+			DecrypterKeyVersion:  types.Int64Value(1),
+		}
+	}
+	tests := []struct {
+		name        string
+		change      func(*provisionerSCEPModel)
+		wantReplace bool
+	}{
+		{name: "unchanged", change: func(*provisionerSCEPModel) {}},
+		{name: "challenge version", change: func(m *provisionerSCEPModel) { m.ChallengeVersion = types.Int64Value(2) }, wantReplace: true},
+		{name: "decrypter key version", change: func(m *provisionerSCEPModel) { m.DecrypterKeyVersion = types.Int64Value(2) }, wantReplace: true},
+		{name: "other argument", change: func(m *provisionerSCEPModel) { m.IncludeRoot = types.BoolValue(false) }, wantReplace: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := &fakeProvisionerClient{getResp: &client.Provisioner{Name: "mdm", Type: "SCEP"}}
+			r := &provisionerResource{client: fake}
+			state := provisionerResourceModel{Name: types.StringValue("mdm"), Type: types.StringValue("SCEP"), SCEP: scep()}
+			plan := provisionerResourceModel{Name: types.StringValue("mdm"), Type: types.StringValue("SCEP"), SCEP: scep()}
+			// The write-only values only ever come from the configuration.
+			plan.SCEP.challenge, plan.SCEP.decrypterKey, plan.SCEP.decrypterKeyPassword = "s3cret", key, "pw"
+			tc.change(plan.SCEP)
+			if _, diags := r.updateProvisioner(context.Background(), &state, &plan); diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %#v", diags)
+			}
+			if fake.replaceCalled != tc.wantReplace {
+				t.Fatalf("expected replace=%t got %t", tc.wantReplace, fake.replaceCalled)
+			}
+			if !tc.wantReplace {
+				return
+			}
+			sent := fake.replaceInput.Details
+			if sent == nil || sent.SCEP == nil || sent.SCEP.Challenge != "s3cret" || sent.SCEP.Decrypter == nil ||
+				string(sent.SCEP.Decrypter.Key) != key || sent.SCEP.Decrypter.KeyPassword != "pw" {
+				t.Fatalf("the write-only values must be sent with the update: %#v", sent)
+			}
+		})
+	}
+}
+
+// testDecrypter returns a self-signed RSA certificate and its key.
+func testDecrypter(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "SCEP decrypter"}}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatalf("create cert: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+}
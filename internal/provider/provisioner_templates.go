@@ -0,0 +1,111 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// validateProvisionerTemplates checks that template data holds JSON objects
+// and that template checksums come with the template they track.
+func validateProvisionerTemplates(m provisionerResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, t := range []struct {
+		name               string
+		template, data, cs types.String
+	}{
+		{"x509", m.X509Template, m.X509TemplateData, m.X509TemplateChecksum},
+		{"ssh", m.SSHTemplate, m.SSHTemplateData, m.SSHTemplateChecksum},
+	} {
+		if v, ok := optionalStringValue(t.data); ok {
+			var obj map[string]any
+			if err := json.Unmarshal([]byte(v), &obj); err != nil || obj == nil {
+				diags.AddAttributeError(path.Root(t.name+"_template_data"), "invalid provisioner configuration",
+					fmt.Sprintf("%s_template_data must be a JSON object, for example jsonencode({ organization = \"Example\" })", t.name))
+			}
+		}
+		if !t.cs.IsNull() && t.template.IsNull() {
+			diags.AddAttributeError(path.Root(t.name+"_template_checksum"), "invalid provisioner configuration",
+				fmt.Sprintf("%s_template_checksum tracks the template named by %s_template, which is not set", t.name, t.name))
+		}
+	}
+	return diags
+}
+
+// templateDataToClient returns template data in compact form for the admin
+// API.
+func templateDataToClient(v types.String) json.RawMessage {
+	s, ok := optionalStringValue(v)
+	if !ok {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(s)); err != nil {
+		return json.RawMessage(s)
+	}
+	return buf.Bytes()
+}
+
+// templateDataValue refreshes template data from step-ca, keeping the
+// stored form when it encodes the same JSON.
+func templateDataValue(stored types.String, remote json.RawMessage) types.String {
+	if len(remote) == 0 {
+		return types.StringNull()
+	}
+	if jsonEquivalent(stored.ValueString(), string(remote)) {
+		return stored
+	}
+	return types.StringValue(string(remote))
+}
+
+// templateDataEqual reports whether two template data values encode the same
+// JSON.
+func templateDataEqual(a, b types.String) bool {
+	if stringAttrEqual(a, b) {
+		return true
+	}
+	if a.IsNull() || b.IsNull() || a.IsUnknown() || b.IsUnknown() {
+		return false
+	}
+	return jsonEquivalent(a.ValueString(), b.ValueString())
+}
+
+// refreshTemplateChecksums replaces the configured template checksums with
+// the checksums of the templates step-ca holds under the referenced names. A
+// template changed outside Terraform, or removed, thereby shows up as drift,
+// and the next apply pushes the provisioner again.
+func refreshTemplateChecksums(ctx context.Context, getter templateGetter, m *provisionerResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, t := range []struct {
+		template types.String
+		cs       *types.String
+	}{
+		{m.X509Template, &m.X509TemplateChecksum},
+		{m.SSHTemplate, &m.SSHTemplateChecksum},
+	} {
+		if t.cs.IsNull() || t.cs.IsUnknown() {
+			continue
+		}
+		name, ok := optionalStringValue(t.template)
+		if !ok {
+			*t.cs = types.StringNull()
+			continue
+		}
+		tmpl, err := getter.GetTemplate(ctx, name)
+		if err != nil {
+			diags.Append(clientErrorDiagnostic("get template failed", "template", err))
+			continue
+		}
+		if tmpl == nil {
+			*t.cs = types.StringNull()
+			continue
+		}
+		*t.cs = types.StringValue(templateChecksum(tmpl.Body))
+	}
+	return diags
+}
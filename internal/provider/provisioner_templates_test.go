@@ -0,0 +1,146 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/z0link/terraform-provider-stepca/internal/client"
+)
+
+func TestValidateProvisionerTemplates(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		model   provisionerResourceModel
+		wantErr bool
+	}{
+		{name: "object", model: provisionerResourceModel{X509TemplateData: types.StringValue(`{"organization": "Example"}`)}},
+		{name: "unknown", model: provisionerResourceModel{SSHTemplateData: types.StringUnknown()}},
+		{name: "array", model: provisionerResourceModel{X509TemplateData: types.StringValue(`["Example"]`)}, wantErr: true},
+		{name: "null json", model: provisionerResourceModel{SSHTemplateData: types.StringValue("null")}, wantErr: true},
+		{name: "not json", model: provisionerResourceModel{SSHTemplateData: types.StringValue("organization=Example")}, wantErr: true},
+		{
+			name:  "checksum with template",
+			model: provisionerResourceModel{X509Template: types.StringValue("leaf"), X509TemplateChecksum: types.StringValue("abc")},
+		},
+		{
+			name:  "checksum with unknown template",
+			model: provisionerResourceModel{SSHTemplate: types.StringUnknown(), SSHTemplateChecksum: types.StringUnknown()},
+		},
+		{
+			name:    "checksum without template",
+			model:   provisionerResourceModel{X509Template: types.StringNull(), SSHTemplate: types.StringNull(), SSHTemplateChecksum: types.StringValue("abc")},
+			wantErr: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if diags := validateProvisionerTemplates(tc.model); diags.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%t got %v", tc.wantErr, diags)
+			}
+		})
+	}
+}
+
+func TestProvisionerTemplateData(t *testing.T) {
+	t.Parallel()
+
+	configured := types.StringValue("{\n  \"organization\": \"Example\",\n  \"ou\": [\"ops\"]\n}")
+	sent := templateDataToClient(configured)
+	if string(sent) != `{"organization":"Example","ou":["ops"]}` {
+		t.Fatalf("unexpected admin API data: %s", sent)
+	}
+	if got := templateDataValue(configured, json.RawMessage(`{"ou":["ops"],"organization":"Example"}`)); got != configured {
+		t.Fatalf("equivalent data must keep its configured form: %s", got)
+	}
+	if got := templateDataValue(configured, json.RawMessage(`{"organization":"Other"}`)); got.ValueString() != `{"organization":"Other"}` {
+		t.Fatalf("changed data must show up as drift: %s", got)
+	}
+	if got := templateDataValue(configured, nil); !got.IsNull() {
+		t.Fatalf("removed data must show up as drift: %s", got)
+	}
+}
+
+func TestProvisionerResourceUpdateTemplates(t *testing.T) {
+	t.Parallel()
+
+	base := func() provisionerResourceModel {
+		return provisionerResourceModel{
+			Name:                 types.StringValue("leaf"),
+			Type:                 types.StringValue("JWK"),
+			X509Template:         types.StringValue("leaf"),
+			X509TemplateData:     types.StringValue(`{"organization": "Example"}`),
+			X509TemplateChecksum: types.StringValue("1111"),
+		}
+	}
+	tests := []struct {
+		name        string
+		change      func(*provisionerResourceModel)
+		wantReplace bool
+	}{
+		{name: "unchanged", change: func(*provisionerResourceModel) {}},
+		{name: "reformatted data", change: func(m *provisionerResourceModel) {
+			m.X509TemplateData = types.StringValue(`{"organization":"Example"}`)
+		}},
+		{name: "changed data", change: func(m *provisionerResourceModel) {
+			m.X509TemplateData = types.StringValue(`{"organization":"Other"}`)
+		}, wantReplace: true},
+		{name: "updated template", change: func(m *provisionerResourceModel) {
+			m.X509TemplateChecksum = types.StringValue("2222")
+		}, wantReplace: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := &fakeProvisionerClient{getResp: &client.Provisioner{Name: "leaf", Type: "JWK", X509Template: "leaf",
+				X509TemplateData: json.RawMessage(`{"organization":"Example"}`)}}
+			r := &provisionerResource{client: fake}
+			state, plan := base(), base()
+			tc.change(&plan)
+			updated, diags := r.updateProvisioner(context.Background(), &state, &plan)
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %#v", diags)
+			}
+			if fake.replaceCalled != tc.wantReplace {
+				t.Fatalf("expected replace=%t got %t", tc.wantReplace, fake.replaceCalled)
+			}
+			if tc.wantReplace && fake.replaceInput.X509Template != "leaf" {
+				t.Fatalf("the template reference must be pushed again: %#v", fake.replaceInput)
+			}
+			if updated.X509TemplateChecksum != plan.X509TemplateChecksum {
+				t.Fatalf("expected the planned checksum in state, got %s", updated.X509TemplateChecksum)
+			}
+		})
+	}
+}
+
+func TestRefreshTemplateChecksums(t *testing.T) {
+	t.Parallel()
+
+	fake := &fakeProvisionerClient{templates: map[string]string{"leaf": "v2", "host": "v1"}}
+	data := provisionerResourceModel{
+		X509Template:         types.StringValue("leaf"),
+		X509TemplateChecksum: types.StringValue(templateChecksum("v1")),
+		SSHTemplate:          types.StringValue("host"),
+		SSHTemplateChecksum:  types.StringValue(templateChecksum("v1")),
+	}
+	if diags := refreshTemplateChecksums(context.Background(), fake, &data); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if data.X509TemplateChecksum.ValueString() != templateChecksum("v2") {
+		t.Fatalf("a template changed outside Terraform must show up as drift: %s", data.X509TemplateChecksum)
+	}
+	if data.SSHTemplateChecksum.ValueString() != templateChecksum("v1") {
+		t.Fatalf("an unchanged template must keep its checksum: %s", data.SSHTemplateChecksum)
+	}
+
+	delete(fake.templates, "leaf")
+	data.SSHTemplateChecksum = types.StringNull()
+	refreshTemplateChecksums(context.Background(), fake, &data)
+	if !data.X509TemplateChecksum.IsNull() || !data.SSHTemplateChecksum.IsNull() {
+		t.Fatalf("a removed template must show up as drift and unset checksums must stay unset: %#v", data)
+	}
+}
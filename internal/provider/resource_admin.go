@@ -2,16 +2,24 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 
 	"github.com/z0link/terraform-provider-stepca/internal/client"
 )
 
-var _ resource.Resource = &adminResource{}
+var (
+	_ resource.Resource                = &adminResource{}
+	_ resource.ResourceWithImportState = &adminResource{}
+)
 
 func NewAdminResource() resource.Resource { return &adminResource{} }
 
@@ -25,6 +33,7 @@ type adminClient interface {
 type adminResource struct{ client adminClient }
 
 type adminResourceModel struct {
+	ID              types.String `tfsdk:"id"`
 	Name            types.String `tfsdk:"name"`
 	ProvisionerName types.String `tfsdk:"provisioner_name"`
 }
@@ -36,7 +45,14 @@ func (r *adminResource) Metadata(ctx context.Context, req resource.MetadataReque
 func (r *adminResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Attributes: map[string]schema.Attribute{
-			"name":             schema.StringAttribute{Required: true},
+			"id": schema.StringAttribute{
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"name": schema.StringAttribute{
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
 			"provisioner_name": schema.StringAttribute{Required: true},
 		},
 	}
@@ -64,9 +80,19 @@ func (r *adminResource) Create(ctx context.Context, req resource.CreateRequest,
 	}
 	a := client.Admin{Name: data.Name.ValueString(), Provisioner: data.ProvisionerName.ValueString()}
 	if err := r.client.CreateAdmin(ctx, a); err != nil {
-		resp.Diagnostics.AddError("create failed", err.Error())
+		resp.Diagnostics.Append(clientErrorDiagnostic("create failed", "admin", err))
+		return
+	}
+	created, err := r.client.GetAdmin(ctx, a.Name, a.Provisioner)
+	if err != nil {
+		resp.Diagnostics.Append(clientErrorDiagnostic("read failed", "admin", err))
 		return
 	}
+	if created == nil {
+		resp.Diagnostics.AddError("read failed", "admin missing after create")
+		return
+	}
+	data.ID = stringValueOrNull(created.ID)
 	diags = resp.State.Set(ctx, &data)
 	resp.Diagnostics.Append(diags...)
 }
@@ -84,19 +110,42 @@ func (r *adminResource) Read(ctx context.Context, req resource.ReadRequest, resp
 	}
 	a, err := r.client.GetAdmin(ctx, data.Name.ValueString(), data.ProvisionerName.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("read failed", err.Error())
+		resp.Diagnostics.Append(clientErrorDiagnostic("read failed", "admin", err))
 		return
 	}
 	if a == nil {
 		resp.State.RemoveResource(ctx)
 		return
 	}
+	data.ID = stringValueOrNull(a.ID)
 	data.Name = types.StringValue(a.Name)
 	data.ProvisionerName = types.StringValue(a.Provisioner)
 	diags = resp.State.Set(ctx, &data)
 	resp.Diagnostics.Append(diags...)
 }
 
+// ImportState adopts an existing admin from an ID of the form
+// "provisioner/name". Read then confirms the admin exists.
+func (r *adminResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	provisioner, name, err := parseAdminImportID(req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("invalid import ID", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("provisioner_name"), provisioner)...)
+}
+
+// parseAdminImportID splits an admin import ID into provisioner and admin
+// name at the first slash, so the admin name may itself contain slashes.
+func parseAdminImportID(id string) (string, string, error) {
+	provisioner, name, ok := strings.Cut(id, "/")
+	if !ok || provisioner == "" || name == "" {
+		return "", "", fmt.Errorf("expected an ID of the form provisioner/name, got %q", id)
+	}
+	return provisioner, name, nil
+}
+
 func (r *adminResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
 	var plan adminResourceModel
 	var state adminResourceModel
@@ -132,13 +181,13 @@ func (r *adminResource) updateAdmin(ctx context.Context, state, plan *adminResou
 	if plan.ProvisionerName.ValueString() != state.ProvisionerName.ValueString() {
 		payload := client.Admin{Name: plan.Name.ValueString(), Provisioner: plan.ProvisionerName.ValueString()}
 		if err := r.client.ReplaceAdmin(ctx, state.Name.ValueString(), state.ProvisionerName.ValueString(), payload); err != nil {
-			diags.AddError("update failed", err.Error())
+			diags.Append(clientErrorDiagnostic("update failed", "admin", err))
 			return nil, diags
 		}
 	}
 	updated, err := r.client.GetAdmin(ctx, plan.Name.ValueString(), plan.ProvisionerName.ValueString())
 	if err != nil {
-		diags.AddError("read failed", err.Error())
+		diags.Append(clientErrorDiagnostic("read failed", "admin", err))
 		return nil, diags
 	}
 	if updated == nil {
@@ -146,6 +195,7 @@ func (r *adminResource) updateAdmin(ctx context.Context, state, plan *adminResou
 		return nil, diags
 	}
 	result := &adminResourceModel{
+		ID:              stringValueOrNull(updated.ID),
 		Name:            types.StringValue(updated.Name),
 		ProvisionerName: types.StringValue(updated.Provisioner),
 	}
@@ -164,7 +214,7 @@ func (r *adminResource) Delete(ctx context.Context, req resource.DeleteRequest,
 		return
 	}
 	if err := r.client.DeleteAdmin(ctx, data.Name.ValueString(), data.ProvisionerName.ValueString()); err != nil {
-		resp.Diagnostics.AddError("delete failed", err.Error())
+		resp.Diagnostics.Append(clientErrorDiagnostic("delete failed", "admin", err))
 		return
 	}
 }
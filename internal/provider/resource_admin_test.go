@@ -7,6 +7,9 @@ import (
 	"github.com/google/go-cmp/cmp"
 	pfresource "github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 
 	"github.com/z0link/terraform-provider-stepca/internal/client"
@@ -21,12 +24,19 @@ func TestAdminResourceSchema(t *testing.T) {
 
 	expected := schema.Schema{
 		Attributes: map[string]schema.Attribute{
-			"name":             schema.StringAttribute{Required: true},
+			"id": schema.StringAttribute{
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"name": schema.StringAttribute{
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
 			"provisioner_name": schema.StringAttribute{Required: true},
 		},
 	}
 
-	if diff := cmp.Diff(expected, resp.Schema); diff != "" {
+	if diff := cmp.Diff(expected, resp.Schema, comparePlanModifiers); diff != "" {
 		t.Fatalf("unexpected schema: (-want +got)\n%s", diff)
 	}
 }
@@ -72,6 +82,36 @@ func TestAdminResourceUpdateNameImmutable(t *testing.T) {
 	}
 }
 
+func TestAdminResourceImportState(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	r := NewAdminResource().(*adminResource)
+	var schemaResp pfresource.SchemaResponse
+	r.Schema(ctx, pfresource.SchemaRequest{}, &schemaResp)
+
+	// Terraform hands ImportState a null state of the resource type.
+	resp := pfresource.ImportStateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	resp.State.Set(ctx, &adminResourceModel{Name: types.StringNull(), ProvisionerName: types.StringNull()})
+	r.ImportState(ctx, pfresource.ImportStateRequest{ID: "admin/ops/alice@example.com"}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+	}
+	var got adminResourceModel
+	resp.State.Get(ctx, &got)
+	if got.ProvisionerName.ValueString() != "admin" || got.Name.ValueString() != "ops/alice@example.com" {
+		t.Fatalf("unexpected imported state: %#v", got)
+	}
+
+	for _, id := range []string{"alice", "/alice", "admin/"} {
+		resp := pfresource.ImportStateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+		r.ImportState(ctx, pfresource.ImportStateRequest{ID: id}, &resp)
+		if !resp.Diagnostics.HasError() {
+			t.Fatalf("expected an error for import ID %q", id)
+		}
+	}
+}
+
 type fakeAdminClient struct {
 	replaceCalled bool
 	getAdminResp  *client.Admin
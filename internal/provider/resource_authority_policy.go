@@ -0,0 +1,185 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/z0link/terraform-provider-stepca/internal/client"
+)
+
+var (
+	_ resource.Resource                   = &authorityPolicyResource{}
+	_ resource.ResourceWithImportState    = &authorityPolicyResource{}
+	_ resource.ResourceWithValidateConfig = &authorityPolicyResource{}
+)
+
+// authorityPolicyID is the ID of the authority policy. step-ca holds at most
+// one.
+const authorityPolicyID = "authority"
+
+func NewAuthorityPolicyResource() resource.Resource { return &authorityPolicyResource{} }
+
+// authorityPolicyGetter captures the helper interface for reading the
+// authority policy.
+type authorityPolicyGetter interface {
+	GetAuthorityPolicy(context.Context) (*client.Policy, error)
+}
+
+type authorityPolicyClient interface {
+	authorityPolicyGetter
+	CreateAuthorityPolicy(context.Context, client.Policy) error
+	UpdateAuthorityPolicy(context.Context, client.Policy) error
+	DeleteAuthorityPolicy(context.Context) error
+}
+
+type authorityPolicyResource struct{ client authorityPolicyClient }
+
+type authorityPolicyModel struct {
+	ID   types.String     `tfsdk:"id"`
+	X509 *x509PolicyModel `tfsdk:"x509"`
+	SSH  *sshPolicyModel  `tfsdk:"ssh"`
+}
+
+func (r *authorityPolicyResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = "stepca_authority_policy"
+}
+
+func (r *authorityPolicyResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Names any provisioner of the authority may issue certificates for.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"x509": policyX509Block(),
+			"ssh":  policySSHBlock(),
+		},
+	}
+}
+
+func (r *authorityPolicyResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data authorityPolicyModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if data.X509 == nil && data.SSH == nil {
+		resp.Diagnostics.AddError("invalid authority policy", "set an x509 or ssh block; remove the resource to remove the authority policy")
+		return
+	}
+	resp.Diagnostics.Append(validatePolicy(path.Empty(), data.X509, data.SSH)...)
+}
+
+func (r *authorityPolicyResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	if c, ok := req.ProviderData.(*client.Client); ok {
+		r.client = c
+	}
+}
+
+func (r *authorityPolicyResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if r.client == nil {
+		resp.Diagnostics.AddError("provider not configured", "missing client")
+		return
+	}
+
+	var data authorityPolicyModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.client.CreateAuthorityPolicy(ctx, data.toClient()); err != nil {
+		resp.Diagnostics.Append(clientErrorDiagnostic("create failed", "authority policy", err))
+		return
+	}
+
+	data.ID = types.StringValue(authorityPolicyID)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *authorityPolicyResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	if r.client == nil {
+		resp.Diagnostics.AddError("provider not configured", "missing client")
+		return
+	}
+
+	var data authorityPolicyModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	p, err := r.client.GetAuthorityPolicy(ctx)
+	if err != nil {
+		resp.Diagnostics.Append(clientErrorDiagnostic("read failed", "authority policy", err))
+		return
+	}
+	if p == nil {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	data.ID = types.StringValue(authorityPolicyID)
+	data.X509 = x509PolicyFromClient(data.X509, p.X509)
+	data.SSH = sshPolicyFromClient(data.SSH, p.SSH)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// ImportState adopts the existing authority policy. Any ID works since there
+// is only one; Read fills in the policy.
+func (r *authorityPolicyResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), authorityPolicyID)...)
+}
+
+func (r *authorityPolicyResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if r.client == nil {
+		resp.Diagnostics.AddError("provider not configured", "missing client")
+		return
+	}
+
+	var data authorityPolicyModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if err := r.client.UpdateAuthorityPolicy(ctx, data.toClient()); err != nil {
+		resp.Diagnostics.Append(clientErrorDiagnostic("update failed", "authority policy", err))
+		return
+	}
+
+	data.ID = types.StringValue(authorityPolicyID)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *authorityPolicyResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if r.client == nil {
+		resp.Diagnostics.AddError("provider not configured", "missing client")
+		return
+	}
+
+	if err := r.client.DeleteAuthorityPolicy(ctx); err != nil {
+		resp.Diagnostics.Append(clientErrorDiagnostic("delete failed", "authority policy", err))
+	}
+}
+
+// toClient converts the policy blocks into the admin API representation. An
+// empty policy is sent as {}.
+func (m authorityPolicyModel) toClient() client.Policy {
+	if p := policyToClient(m.X509, m.SSH); p != nil {
+		return *p
+	}
+	return client.Policy{}
+}
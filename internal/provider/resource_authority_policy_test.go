@@ -0,0 +1,143 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	pfresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/z0link/terraform-provider-stepca/internal/client"
+)
+
+type fakeAuthorityPolicyClient struct {
+	policy  *client.Policy
+	created bool
+	updated bool
+}
+
+func (f *fakeAuthorityPolicyClient) GetAuthorityPolicy(context.Context) (*client.Policy, error) {
+	return f.policy, nil
+}
+
+func (f *fakeAuthorityPolicyClient) CreateAuthorityPolicy(ctx context.Context, p client.Policy) error {
+	f.created = true
+	f.policy = &p
+	return nil
+}
+
+func (f *fakeAuthorityPolicyClient) UpdateAuthorityPolicy(ctx context.Context, p client.Policy) error {
+	f.updated = true
+	f.policy = &p
+	return nil
+}
+
+func (f *fakeAuthorityPolicyClient) DeleteAuthorityPolicy(context.Context) error {
+	f.policy = nil
+	return nil
+}
+
+// testX509Names returns allow or deny names with only DNS names set.
+func testX509Names(dns ...string) *x509NamesModel {
+	return &x509NamesModel{
+		CommonNames: policyList(),
+		DNS:         policyList(dns...),
+		IPs:         policyList(),
+		Emails:      policyList(),
+		URIs:        policyList(),
+	}
+}
+
+func TestAuthorityPolicyResourceSchema(t *testing.T) {
+	t.Parallel()
+
+	var resp pfresource.SchemaResponse
+	NewAuthorityPolicyResource().Schema(context.Background(), pfresource.SchemaRequest{}, &resp)
+	if diags := resp.Schema.ValidateImplementation(context.Background()); diags.HasError() {
+		t.Fatalf("invalid schema: %v", diags)
+	}
+	for _, name := range []string{"x509", "ssh"} {
+		if _, ok := resp.Schema.Blocks[name]; !ok {
+			t.Fatalf("missing %s block", name)
+		}
+	}
+}
+
+func TestAuthorityPolicyResourceValidateConfig(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	r := &authorityPolicyResource{}
+	var schemaResp pfresource.SchemaResponse
+	r.Schema(ctx, pfresource.SchemaRequest{}, &schemaResp)
+
+	tests := []struct {
+		name    string
+		model   authorityPolicyModel
+		wantErr bool
+	}{
+		{name: "dns", model: authorityPolicyModel{X509: &x509PolicyModel{Allow: testX509Names("*.example.com")}}},
+		{name: "no blocks", model: authorityPolicyModel{}, wantErr: true},
+		{name: "bad dns", model: authorityPolicyModel{X509: &x509PolicyModel{Deny: testX509Names("*.*.example.com")}}, wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.model.ID = types.StringNull()
+			plan := tfsdk.Plan{Schema: schemaResp.Schema}
+			if diags := plan.Set(ctx, &tc.model); diags.HasError() {
+				t.Fatalf("set config: %v", diags)
+			}
+			var resp pfresource.ValidateConfigResponse
+			r.ValidateConfig(ctx, pfresource.ValidateConfigRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: plan.Raw}}, &resp)
+			if resp.Diagnostics.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%t got %v", tc.wantErr, resp.Diagnostics)
+			}
+		})
+	}
+}
+
+func TestAuthorityPolicyResourceLifecycle(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fake := &fakeAuthorityPolicyClient{}
+	r := &authorityPolicyResource{client: fake}
+	var schemaResp pfresource.SchemaResponse
+	r.Schema(ctx, pfresource.SchemaRequest{}, &schemaResp)
+
+	plan := tfsdk.Plan{Schema: schemaResp.Schema}
+	plan.Set(ctx, &authorityPolicyModel{
+		ID:   types.StringUnknown(),
+		X509: &x509PolicyModel{AllowWildcardNames: types.BoolNull(), Allow: testX509Names("*.example.com")},
+	})
+	createResp := pfresource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	r.Create(ctx, pfresource.CreateRequest{Plan: plan}, &createResp)
+	if createResp.Diagnostics.HasError() {
+		t.Fatalf("create: %v", createResp.Diagnostics)
+	}
+	if !fake.created || fake.policy.X509 == nil || fake.policy.X509.Allow.DNS[0] != "*.example.com" || fake.policy.SSH != nil {
+		t.Fatalf("unexpected created policy: %#v", fake.policy)
+	}
+
+	// A name added outside Terraform shows up as drift.
+	fake.policy.X509.Allow.DNS = append(fake.policy.X509.Allow.DNS, "example.org")
+	readResp := pfresource.ReadResponse{State: createResp.State}
+	r.Read(ctx, pfresource.ReadRequest{State: createResp.State}, &readResp)
+	if readResp.Diagnostics.HasError() {
+		t.Fatalf("read: %v", readResp.Diagnostics)
+	}
+	var got authorityPolicyModel
+	readResp.State.Get(ctx, &got)
+	if got.ID.ValueString() != authorityPolicyID || len(got.X509.Allow.DNS.Elements()) != 2 || got.SSH != nil {
+		t.Fatalf("unexpected state: %#v", got)
+	}
+
+	// A policy removed outside Terraform removes the resource from state.
+	fake.policy = nil
+	readResp = pfresource.ReadResponse{State: createResp.State}
+	r.Read(ctx, pfresource.ReadRequest{State: createResp.State}, &readResp)
+	if readResp.Diagnostics.HasError() || !readResp.State.Raw.IsNull() {
+		t.Fatalf("expected the resource to be removed, got %v %v", readResp.State.Raw, readResp.Diagnostics)
+	}
+}
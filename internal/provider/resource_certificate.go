@@ -3,28 +3,43 @@ package provider
 import (
 	"bytes"
 	"context"
+	"crypto"
+	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 
 	"github.com/z0link/terraform-provider-stepca/internal/client"
 )
 
-var _ resource.Resource = &certificateResource{}
+var (
+	_ resource.Resource                   = &certificateResource{}
+	_ resource.ResourceWithValidateConfig = &certificateResource{}
+	_ resource.ResourceWithModifyPlan     = &certificateResource{}
+	_ resource.ResourceWithImportState    = &certificateResource{}
+)
 
 func NewCertificateResource() resource.Resource {
 	return &certificateResource{}
 }
 
 type certificateClient interface {
-	Sign(ctx context.Context, csr string) ([]byte, error)
+	Sign(ctx context.Context, csr string) (*client.SignResult, error)
 	Certificate(ctx context.Context, serial string) ([]byte, bool, error)
+	Revoke(ctx context.Context, req client.RevokeRequest) error
+	Renew(ctx context.Context, cert tls.Certificate) (*client.SignResult, error)
 }
 
 type certificateResource struct {
@@ -32,9 +47,19 @@ type certificateResource struct {
 }
 
 type certificateResourceModel struct {
-	CSR         types.String `tfsdk:"csr"`
-	Cert        types.String `tfsdk:"certificate"`
-	ForceRotate types.Bool   `tfsdk:"force_rotate"`
+	CSR                  types.String  `tfsdk:"csr"`
+	Cert                 types.String  `tfsdk:"certificate"`
+	CACertificate        types.String  `tfsdk:"ca_certificate"`
+	CertificateChain     types.List    `tfsdk:"certificate_chain"`
+	FullChainPEM         types.String  `tfsdk:"full_chain_pem"`
+	ForceRotate          types.Bool    `tfsdk:"force_rotate"`
+	PrivateKeyPEM        types.String  `tfsdk:"private_key_pem"`
+	RevokeOnDestroy      types.Bool    `tfsdk:"revoke_on_destroy"`
+	RevocationReason     types.String  `tfsdk:"revocation_reason"`
+	RevocationReasonCode types.Int64   `tfsdk:"revocation_reason_code"`
+	RenewBefore          types.String  `tfsdk:"renew_before"`
+	MinRemainingFraction types.Float64 `tfsdk:"min_remaining_fraction"`
+	certificateDetailsModel
 }
 
 func (r *certificateResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -42,16 +67,129 @@ func (r *certificateResource) Metadata(ctx context.Context, req resource.Metadat
 }
 
 func (r *certificateResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
-	resp.Schema = schema.Schema{
+	schemaDef := schema.Schema{
 		Attributes: map[string]schema.Attribute{
-			"csr":         schema.StringAttribute{Required: true},
-			"certificate": schema.StringAttribute{Computed: true},
+			"csr": schema.StringAttribute{
+				Optional:    true,
+				Description: "PEM encoded certificate signing request. Omit it to have the provider build the CSR from private_key_pem, subject and dns_names.",
+			},
+			"certificate": schema.StringAttribute{
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"ca_certificate": schema.StringAttribute{
+				Computed:      true,
+				Description:   "PEM encoded certificate of the CA that issued the certificate.",
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"certificate_chain": schema.ListAttribute{
+				Computed:      true,
+				ElementType:   types.StringType,
+				Description:   "PEM encoded certificates of the chain, starting with the issued certificate followed by its intermediates.",
+				PlanModifiers: []planmodifier.List{listplanmodifier.UseStateForUnknown()},
+			},
+			"full_chain_pem": schema.StringAttribute{
+				Computed:      true,
+				Description:   "The issued certificate and its intermediates concatenated into one PEM bundle.",
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
 			"force_rotate": schema.BoolAttribute{
 				Optional:    true,
 				Description: "Toggle this value to force Terraform to request a new certificate without changing the CSR.",
 			},
+			"private_key_pem": schema.StringAttribute{
+				Optional:    true,
+				Sensitive:   true,
+				Description: "PEM encoded private key matching the CSR. When set, renewal and revocation authenticate with mTLS using the certificate instead of a provisioner token.",
+			},
+			"revoke_on_destroy": schema.BoolAttribute{
+				Optional:    true,
+				Description: "Revoke the certificate through step-ca when the resource is destroyed. Defaults to false, which only removes it from state.",
+			},
+			"revocation_reason": schema.StringAttribute{
+				Optional:    true,
+				Description: "Free-form reason recorded with the revocation.",
+			},
+			"revocation_reason_code": schema.Int64Attribute{
+				Optional:    true,
+				Description: "RFC 5280 reason code recorded with the revocation (0-10, except 7). Defaults to 0 (unspecified).",
+			},
+			"renew_before": schema.StringAttribute{
+				Optional:    true,
+				Description: "Renew the certificate once less than this duration (for example \"720h\") of its validity remains.",
+			},
+			"min_remaining_fraction": schema.Float64Attribute{
+				Optional:    true,
+				Description: "Renew the certificate once less than this fraction of its total validity remains, between 0 and 1.",
+			},
 		},
 	}
+	for name, a := range certificateDetailsAttributes() {
+		schemaDef.Attributes[name] = a
+	}
+	schemaDef.Attributes["subject"] = schema.StringAttribute{
+		Optional:      true,
+		Computed:      true,
+		Description:   "Subject distinguished name such as \"CN=svc.example.com,O=Example\". Set it instead of csr to request a certificate for private_key_pem; otherwise it reports the issued certificate's subject.",
+		PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+	}
+	schemaDef.Attributes["dns_names"] = schema.ListAttribute{
+		Optional:      true,
+		Computed:      true,
+		ElementType:   types.StringType,
+		Description:   "DNS subject alternative names. Set it instead of csr to request a certificate for private_key_pem; otherwise it reports the issued certificate's DNS names.",
+		PlanModifiers: []planmodifier.List{listplanmodifier.UseStateForUnknown()},
+	}
+	resp.Schema = schemaDef
+}
+
+func (r *certificateResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data certificateResourceModel
+	diags := req.Config.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(validateCertificateRequest(data)...)
+	resp.Diagnostics.Append(validateRevocationReasonCode(data.RevocationReasonCode)...)
+	_, _, renewDiags := renewalSettings(data)
+	resp.Diagnostics.Append(renewDiags...)
+}
+
+// ModifyPlan plans a new certificate once the stored one enters its renewal
+// window, so renewal happens on the next apply without touching the config.
+// It also marks the certificate attributes unknown when a changed request
+// forces a new certificate, since they otherwise keep their state values.
+func (r *certificateResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+	var plan, state certificateResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !needsCertificateRotation(plan, state) && !certificateRenewalDue(plan, state, time.Now()) {
+		return
+	}
+	var config certificateResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	var configured []string
+	if !config.Subject.IsNull() {
+		configured = append(configured, "subject")
+	}
+	if !config.DNSNames.IsNull() {
+		configured = append(configured, "dns_names")
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("certificate"), types.StringUnknown())...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("ca_certificate"), types.StringUnknown())...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("certificate_chain"), types.ListUnknown(types.StringType))...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("full_chain_pem"), types.StringUnknown())...)
+	resp.Diagnostics.Append(markCertificateDetailsUnknown(ctx, &resp.Plan, configured...)...)
 }
 
 func (r *certificateResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
@@ -75,12 +213,18 @@ func (r *certificateResource) Create(ctx context.Context, req resource.CreateReq
 		resp.Diagnostics.AddError("provider not configured", "missing client")
 		return
 	}
-	certPEM, err := r.client.Sign(ctx, data.CSR.ValueString())
+	csr, csrDiags := data.certificateRequest(ctx)
+	resp.Diagnostics.Append(csrDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	result, err := r.client.Sign(ctx, csr)
 	if err != nil {
-		resp.Diagnostics.AddError("sign failed", err.Error())
+		resp.Diagnostics.Append(clientErrorDiagnostic("sign failed", "certificate", err))
 		return
 	}
-	data.Cert = types.StringValue(string(certPEM))
+	resp.Diagnostics.Append(data.setSignResult(ctx, result)...)
+	resp.Diagnostics.Append(data.refreshDetails(ctx)...)
 	diags = resp.State.Set(ctx, &data)
 	resp.Diagnostics.Append(diags...)
 }
@@ -103,11 +247,75 @@ func (r *certificateResource) Read(ctx context.Context, req resource.ReadRequest
 		resp.State.RemoveResource(ctx)
 		return
 	}
+	resp.Diagnostics.Append(data.refreshDetails(ctx)...)
 
 	diags = resp.State.Set(ctx, &data)
 	resp.Diagnostics.Append(diags...)
 }
 
+// ImportState adopts an issued certificate by its hex serial number, as shown
+// in serial_number. The certificate and any chain the CA returns are stored;
+// Read then verifies them and fills in the details.
+func (r *certificateResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	if r.client == nil {
+		resp.Diagnostics.AddError("provider not configured", "missing client")
+		return
+	}
+	serial := normalizeSerial(req.ID)
+	remotePEM, found, err := r.client.Certificate(ctx, serial)
+	if err != nil {
+		resp.Diagnostics.Append(clientErrorDiagnostic("certificate lookup failed", "certificate", err))
+		return
+	}
+	if !found {
+		resp.Diagnostics.AddError("certificate not found", fmt.Sprintf("The CA has no certificate with serial number %q.", serial))
+		return
+	}
+	certs, err := parseCertificates(string(remotePEM))
+	if err != nil {
+		resp.Diagnostics.AddError("certificate parse failed", err.Error())
+		return
+	}
+
+	result := &client.SignResult{}
+	for _, cert := range certs {
+		result.CertChain = append(result.CertChain, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})))
+	}
+	result.Certificate = result.CertChain[0]
+	if len(result.CertChain) > 1 {
+		result.CA = result.CertChain[1]
+	}
+	data := certificateResourceModel{
+		CSR:                  types.StringNull(),
+		ForceRotate:          types.BoolNull(),
+		PrivateKeyPEM:        types.StringNull(),
+		RevokeOnDestroy:      types.BoolNull(),
+		RevocationReason:     types.StringNull(),
+		RevocationReasonCode: types.Int64Null(),
+		RenewBefore:          types.StringNull(),
+		MinRemainingFraction: types.Float64Null(),
+	}
+	resp.Diagnostics.Append(data.setSignResult(ctx, result)...)
+	if result.CA == "" {
+		data.CACertificate = types.StringNull()
+	}
+	resp.Diagnostics.Append(data.refreshDetails(ctx)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// normalizeSerial accepts serial numbers in the formats step prints them,
+// such as "0x4d2" or "04:d2", and returns the lowercase hex form the CA
+// looks certificates up by.
+func normalizeSerial(id string) string {
+	serial := strings.ToLower(strings.TrimSpace(id))
+	serial = strings.TrimPrefix(serial, "0x")
+	serial = strings.ReplaceAll(serial, ":", "")
+	return strings.TrimLeft(serial, "0")
+}
+
 func (r *certificateResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
 	var state certificateResourceModel
 	var plan certificateResourceModel
@@ -127,15 +335,166 @@ func (r *certificateResource) Update(ctx context.Context, req resource.UpdateReq
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	resp.Diagnostics.Append(updated.refreshDetails(ctx)...)
 
 	diags = resp.State.Set(ctx, &updated)
 	resp.Diagnostics.Append(diags...)
 }
 
 func (r *certificateResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data certificateResourceModel
+	diags := req.State.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.revokeCertificate(ctx, data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 	resp.State.RemoveResource(ctx)
 }
 
+// revokeCertificate revokes the stored certificate when revoke_on_destroy is
+// set. Without it the certificate is only forgotten.
+func (r *certificateResource) revokeCertificate(ctx context.Context, data certificateResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if !boolValue(data.RevokeOnDestroy) || data.Cert.ValueString() == "" {
+		return diags
+	}
+	if r.client == nil {
+		diags = append(diags, diag.NewErrorDiagnostic("provider not configured", "missing client"))
+		return diags
+	}
+
+	cert, err := parseCertificate(data.Cert.ValueString())
+	if err != nil {
+		diags = append(diags, diag.NewErrorDiagnostic("certificate parse failed", err.Error()))
+		return diags
+	}
+
+	revokeReq := client.RevokeRequest{
+		Serial:     cert.SerialNumber.String(),
+		ReasonCode: int(data.RevocationReasonCode.ValueInt64()),
+		Reason:     data.RevocationReason.ValueString(),
+	}
+	if keyPEM := data.PrivateKeyPEM.ValueString(); keyPEM != "" {
+		pair, err := tls.X509KeyPair([]byte(data.Cert.ValueString()), []byte(keyPEM))
+		if err != nil {
+			diags = append(diags, diag.NewErrorDiagnostic("invalid private key", fmt.Sprintf("private_key_pem does not match the certificate: %v", err)))
+			return diags
+		}
+		revokeReq.ClientCertificate = &pair
+	}
+
+	if err := r.client.Revoke(ctx, revokeReq); err != nil {
+		diags = append(diags, clientErrorDiagnostic("revoke failed", "certificate", err))
+	}
+	return diags
+}
+
+// validateRevocationReasonCode accepts the RFC 5280 CRLReason values. Code 7
+// is unassigned.
+func validateRevocationReasonCode(v types.Int64) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if v.IsNull() || v.IsUnknown() {
+		return diags
+	}
+	if code := v.ValueInt64(); code < 0 || code > 10 || code == 7 {
+		diags.AddAttributeError(
+			path.Root("revocation_reason_code"),
+			"invalid revocation reason code",
+			fmt.Sprintf("revocation_reason_code must be between 0 and 10 and not 7, got %d.", code),
+		)
+	}
+	return diags
+}
+
+// setSignResult stores the certificate and chain returned by /sign or /renew.
+func (m *certificateResourceModel) setSignResult(ctx context.Context, result *client.SignResult) diag.Diagnostics {
+	chain := result.Chain()
+	m.Cert = types.StringValue(result.Certificate)
+	m.CACertificate = types.StringValue(result.CA)
+	m.FullChainPEM = types.StringValue(joinPEM(chain))
+	list, diags := types.ListValueFrom(ctx, types.StringType, chain)
+	m.CertificateChain = list
+	return diags
+}
+
+// refreshDetails recomputes the parsed certificate attributes from Cert.
+// Without a csr, subject and dns_names are arguments and keep their
+// configured values.
+func (m *certificateResourceModel) refreshDetails(ctx context.Context) diag.Diagnostics {
+	details, diags := newCertificateDetails(ctx, m.Cert.ValueString())
+	if m.CSR.IsNull() {
+		if !m.Subject.IsNull() && !m.Subject.IsUnknown() {
+			details.Subject = m.Subject
+		}
+		if !m.DNSNames.IsNull() && !m.DNSNames.IsUnknown() {
+			details.DNSNames = m.DNSNames
+		}
+	}
+	m.certificateDetailsModel = details
+	return diags
+}
+
+// certificateRequest returns the configured CSR or builds one from
+// private_key_pem, subject and dns_names.
+func (m *certificateResourceModel) certificateRequest(ctx context.Context) (string, diag.Diagnostics) {
+	if m.CSR.ValueString() != "" {
+		return m.CSR.ValueString(), nil
+	}
+	subject := m.Subject
+	if subject.IsUnknown() {
+		subject = types.StringNull()
+	}
+	dnsNames := m.DNSNames
+	if dnsNames.IsUnknown() {
+		dnsNames = types.ListNull(types.StringType)
+	}
+	return csrResourceModel{
+		PrivateKeyPEM: m.PrivateKeyPEM,
+		Subject:       subject,
+		DNSNames:      dnsNames,
+	}.build(ctx)
+}
+
+// validateCertificateRequest checks that exactly one way of requesting the
+// certificate is configured: a csr, or private_key_pem with subject or
+// dns_names.
+func validateCertificateRequest(data certificateResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if data.CSR.IsUnknown() {
+		return diags
+	}
+	if !data.CSR.IsNull() {
+		for name, v := range map[string]attr.Value{"subject": data.Subject, "dns_names": data.DNSNames} {
+			if !v.IsNull() {
+				diags.AddAttributeError(path.Root(name), "conflicting certificate request",
+					fmt.Sprintf("%s can only be set when csr is omitted; it is taken from the CSR otherwise", name))
+			}
+		}
+		return diags
+	}
+	if data.PrivateKeyPEM.IsNull() {
+		diags.AddAttributeError(path.Root("csr"), "missing certificate request",
+			"set csr, or private_key_pem together with subject or dns_names")
+		return diags
+	}
+	if data.Subject.IsNull() && data.DNSNames.IsNull() {
+		diags.AddAttributeError(path.Root("subject"), "missing certificate request",
+			"subject or dns_names is required when csr is omitted")
+	}
+	if !data.Subject.IsUnknown() {
+		if _, err := parseDistinguishedName(data.Subject.ValueString()); err != nil {
+			diags.AddAttributeError(path.Root("subject"), "invalid subject", err.Error())
+		}
+	}
+	return diags
+}
+
 func (r *certificateResource) shouldKeepCertificate(ctx context.Context, data *certificateResourceModel) (bool, diag.Diagnostics) {
 	var diags diag.Diagnostics
 
@@ -163,7 +522,7 @@ func (r *certificateResource) shouldKeepCertificate(ctx context.Context, data *c
 	serial := strings.ToLower(cert.SerialNumber.Text(16))
 	remotePEM, found, err := r.client.Certificate(ctx, serial)
 	if err != nil {
-		diags = append(diags, diag.NewErrorDiagnostic("certificate lookup failed", err.Error()))
+		diags = append(diags, clientErrorDiagnostic("certificate lookup failed", "certificate", err))
 		return true, diags
 	}
 
@@ -189,14 +548,81 @@ func (r *certificateResource) shouldKeepCertificate(ctx context.Context, data *c
 		return false, diags
 	}
 
+	if err := checkCertificateChain(ctx, cert, data.CertificateChain, string(remotePEM)); err != nil {
+		diags = append(diags, diag.NewWarningDiagnostic(
+			"certificate chain drift detected",
+			fmt.Sprintf("The stored certificate chain no longer matches (%v). Removing it from state so Terraform can request a new certificate.", err),
+		))
+		return false, diags
+	}
+
 	return true, diags
 }
 
+// checkCertificateChain verifies that the stored chain starts with leaf and
+// that every certificate is signed by the next one. When the CA returned a
+// bundle for the serial its intermediates must match the stored ones. State
+// written before the chain was tracked has no chain and is accepted.
+func checkCertificateChain(ctx context.Context, leaf *x509.Certificate, stored types.List, remotePEM string) error {
+	if stored.IsNull() || stored.IsUnknown() {
+		return nil
+	}
+	var pems []string
+	if diags := stored.ElementsAs(ctx, &pems, false); diags.HasError() {
+		return fmt.Errorf("invalid certificate_chain in state")
+	}
+	if len(pems) == 0 {
+		return nil
+	}
+	chain, err := parseCertificates(joinPEM(pems))
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(chain[0].Raw, leaf.Raw) {
+		return fmt.Errorf("chain does not start with the certificate")
+	}
+	for i := 0; i+1 < len(chain); i++ {
+		if err := chain[i].CheckSignatureFrom(chain[i+1]); err != nil {
+			return fmt.Errorf("%s is not issued by %s: %w", chain[i].Subject, chain[i+1].Subject, err)
+		}
+	}
+
+	remote, err := parseCertificates(remotePEM)
+	if err != nil || len(remote) < 2 {
+		return nil
+	}
+	if len(remote) != len(chain) {
+		return fmt.Errorf("the CA returned %d chain certificates, state has %d", len(remote), len(chain))
+	}
+	for i := range remote {
+		if !bytes.Equal(remote[i].Raw, chain[i].Raw) {
+			return fmt.Errorf("the CA returned a different %s", remote[i].Subject)
+		}
+	}
+	return nil
+}
+
+// joinPEM concatenates PEM blocks, making sure each ends with a newline.
+func joinPEM(blocks []string) string {
+	var b strings.Builder
+	for _, block := range blocks {
+		b.WriteString(block)
+		if !strings.HasSuffix(block, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
 func (r *certificateResource) applyCertificateUpdate(ctx context.Context, plan, state certificateResourceModel) (certificateResourceModel, diag.Diagnostics) {
 	var diags diag.Diagnostics
 
-	if !needsCertificateRotation(plan, state) {
+	rotate := needsCertificateRotation(plan, state)
+	if !rotate && !certificateRenewalDue(plan, state, time.Now()) {
 		plan.Cert = state.Cert
+		plan.CACertificate = state.CACertificate
+		plan.CertificateChain = state.CertificateChain
+		plan.FullChainPEM = state.FullChainPEM
 		return plan, diags
 	}
 
@@ -205,23 +631,157 @@ func (r *certificateResource) applyCertificateUpdate(ctx context.Context, plan,
 		return plan, diags
 	}
 
-	certPEM, err := r.client.Sign(ctx, plan.CSR.ValueString())
+	if !rotate && canRenew(plan, state, time.Now()) {
+		pair, err := tls.X509KeyPair([]byte(state.Cert.ValueString()), []byte(plan.PrivateKeyPEM.ValueString()))
+		if err != nil {
+			diags = append(diags, diag.NewErrorDiagnostic("invalid private key", fmt.Sprintf("private_key_pem does not match the certificate: %v", err)))
+			return plan, diags
+		}
+		result, err := r.client.Renew(ctx, pair)
+		if err != nil {
+			diags = append(diags, clientErrorDiagnostic("renew failed", "certificate", err))
+			return plan, diags
+		}
+		diags.Append(plan.setSignResult(ctx, result)...)
+		return plan, diags
+	}
+
+	csr, csrDiags := plan.certificateRequest(ctx)
+	diags.Append(csrDiags...)
+	if diags.HasError() {
+		return plan, diags
+	}
+	result, err := r.client.Sign(ctx, csr)
 	if err != nil {
-		diags = append(diags, diag.NewErrorDiagnostic("sign failed", err.Error()))
+		diags = append(diags, clientErrorDiagnostic("sign failed", "certificate", err))
 		return plan, diags
 	}
 
-	plan.Cert = types.StringValue(string(certPEM))
+	diags.Append(plan.setSignResult(ctx, result)...)
 	return plan, diags
 }
 
 func needsCertificateRotation(plan, state certificateResourceModel) bool {
+	if importedCertificateMatches(plan, state) {
+		return boolValue(plan.ForceRotate) != boolValue(state.ForceRotate)
+	}
 	if plan.CSR.ValueString() != state.CSR.ValueString() {
 		return true
 	}
+	if plan.CSR.IsNull() {
+		// The CSR is generated from these, so a change needs a new certificate.
+		// Unknown values are computed from the current certificate.
+		if plan.PrivateKeyPEM.ValueString() != state.PrivateKeyPEM.ValueString() {
+			return true
+		}
+		if !plan.Subject.IsUnknown() && plan.Subject.ValueString() != state.Subject.ValueString() {
+			return true
+		}
+		if !plan.DNSNames.IsUnknown() && !plan.DNSNames.Equal(state.DNSNames) {
+			return true
+		}
+	}
 	return boolValue(plan.ForceRotate) != boolValue(state.ForceRotate)
 }
 
+// importedCertificateMatches reports whether state came from an import, so no
+// csr or private key is recorded yet, and the configured csr or private key
+// belongs to the imported certificate. The certificate is then adopted as is.
+func importedCertificateMatches(plan, state certificateResourceModel) bool {
+	if !state.CSR.IsNull() || !state.PrivateKeyPEM.IsNull() || state.Cert.ValueString() == "" {
+		return false
+	}
+	cert, err := parseCertificate(state.Cert.ValueString())
+	if err != nil {
+		return false
+	}
+	var pub crypto.PublicKey
+	switch {
+	case plan.CSR.ValueString() != "":
+		csr, err := parseCertificateRequestPEM(plan.CSR.ValueString())
+		if err != nil {
+			return false
+		}
+		pub = csr.PublicKey
+	case plan.PrivateKeyPEM.ValueString() != "":
+		key, err := parsePrivateKeyPEM(plan.PrivateKeyPEM.ValueString())
+		if err != nil {
+			return false
+		}
+		pub = key.Public()
+	default:
+		return false
+	}
+	certPub, ok := cert.PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && certPub.Equal(pub)
+}
+
+// certificateRenewalDue reports whether the stored certificate is inside the
+// renewal window configured in plan.
+func certificateRenewalDue(plan, state certificateResourceModel, now time.Time) bool {
+	if state.Cert.ValueString() == "" {
+		return false
+	}
+	cert, err := parseCertificate(state.Cert.ValueString())
+	if err != nil {
+		return false
+	}
+	renewBefore, fraction, diags := renewalSettings(plan)
+	if diags.HasError() {
+		return false
+	}
+	return renewalDue(cert, renewBefore, fraction, now)
+}
+
+// renewalDue reports whether cert has expired or less than renewBefore or
+// fraction of its validity remains. Zero values disable the respective check.
+func renewalDue(cert *x509.Certificate, renewBefore time.Duration, fraction float64, now time.Time) bool {
+	remaining := cert.NotAfter.Sub(now)
+	if remaining <= 0 {
+		return true
+	}
+	if renewBefore > 0 && remaining <= renewBefore {
+		return true
+	}
+	if total := cert.NotAfter.Sub(cert.NotBefore); fraction > 0 && total > 0 {
+		return float64(remaining)/float64(total) < fraction
+	}
+	return false
+}
+
+// canRenew reports whether the stored certificate can be renewed through
+// /renew: that needs its private key and a certificate that has not expired.
+func canRenew(plan, state certificateResourceModel, now time.Time) bool {
+	if plan.PrivateKeyPEM.ValueString() == "" {
+		return false
+	}
+	cert, err := parseCertificate(state.Cert.ValueString())
+	return err == nil && now.Before(cert.NotAfter)
+}
+
+func renewalSettings(data certificateResourceModel) (time.Duration, float64, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	var renewBefore time.Duration
+	var fraction float64
+
+	if !data.RenewBefore.IsNull() && !data.RenewBefore.IsUnknown() {
+		d, err := time.ParseDuration(data.RenewBefore.ValueString())
+		if err != nil || d <= 0 {
+			diags.AddAttributeError(path.Root("renew_before"), "invalid renewal configuration",
+				fmt.Sprintf("renew_before must be a positive duration such as \"720h\", got %q", data.RenewBefore.ValueString()))
+		}
+		renewBefore = d
+	}
+	if !data.MinRemainingFraction.IsNull() && !data.MinRemainingFraction.IsUnknown() {
+		fraction = data.MinRemainingFraction.ValueFloat64()
+		if fraction <= 0 || fraction >= 1 {
+			diags.AddAttributeError(path.Root("min_remaining_fraction"), "invalid renewal configuration",
+				fmt.Sprintf("min_remaining_fraction must be greater than 0 and less than 1, got %g", fraction))
+		}
+	}
+	return renewBefore, fraction, diags
+}
+
 func boolValue(v types.Bool) bool {
 	if v.IsNull() || v.IsUnknown() {
 		return false
@@ -240,3 +800,28 @@ func parseCertificate(pemData string) (*x509.Certificate, error) {
 	}
 	return cert, nil
 }
+
+// parseCertificates decodes every CERTIFICATE block of a PEM bundle.
+func parseCertificates(pemData string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := []byte(pemData)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found in PEM data")
+	}
+	return certs, nil
+}
@@ -4,32 +4,46 @@ import (
 	"context"
 	"crypto/ed25519"
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/hex"
 	"encoding/pem"
 	"fmt"
 	"math/big"
+	"net"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/z0link/terraform-provider-stepca/internal/client"
 )
 
 type fakeCertificateClient struct {
-	t         *testing.T
-	serial    string
-	pem       string
-	found     bool
-	err       error
-	signPEM   string
-	signErr   error
-	signCSR   string
-	signCalls int
+	t          *testing.T
+	serial     string
+	pem        string
+	found      bool
+	err        error
+	signPEM    string
+	signErr    error
+	signCSR    string
+	signCalls  int
+	revokeErr  error
+	revoked    []client.RevokeRequest
+	renewPEM   string
+	renewErr   error
+	renewCalls int
 }
 
-func (f *fakeCertificateClient) Sign(ctx context.Context, csr string) ([]byte, error) {
+func (f *fakeCertificateClient) Sign(ctx context.Context, csr string) (*client.SignResult, error) {
 	f.signCalls++
 	f.signCSR = csr
 	if f.signErr != nil {
@@ -38,7 +52,7 @@ func (f *fakeCertificateClient) Sign(ctx context.Context, csr string) ([]byte, e
 	if f.signPEM == "" {
 		return nil, fmt.Errorf("sign response not configured")
 	}
-	return []byte(f.signPEM), nil
+	return &client.SignResult{Certificate: f.signPEM, CA: "ca-cert", CertChain: []string{f.signPEM, "ca-cert"}}, nil
 }
 
 func (f *fakeCertificateClient) Certificate(ctx context.Context, serial string) ([]byte, bool, error) {
@@ -54,6 +68,19 @@ func (f *fakeCertificateClient) Certificate(ctx context.Context, serial string)
 	return []byte(f.pem), true, nil
 }
 
+func (f *fakeCertificateClient) Revoke(ctx context.Context, req client.RevokeRequest) error {
+	f.revoked = append(f.revoked, req)
+	return f.revokeErr
+}
+
+func (f *fakeCertificateClient) Renew(ctx context.Context, cert tls.Certificate) (*client.SignResult, error) {
+	f.renewCalls++
+	if f.renewErr != nil {
+		return nil, f.renewErr
+	}
+	return &client.SignResult{Certificate: f.renewPEM}, nil
+}
+
 func TestCertificateResourceShouldKeepCertificate(t *testing.T) {
 	t.Parallel()
 
@@ -159,6 +186,8 @@ func TestCertificateResourceApplyCertificateUpdate(t *testing.T) {
 	ctx := context.Background()
 
 	existingCert := types.StringValue("state-cert")
+	validCert, validKey := testCertificateWithKey(t, 10, "valid.test")
+	expiredCert, expiredKey := testCertificateValidity(t, 11, "expired.test", time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour))
 
 	tests := []struct {
 		name          string
@@ -168,6 +197,7 @@ func TestCertificateResourceApplyCertificateUpdate(t *testing.T) {
 		wantCert      string
 		wantNullCert  bool
 		wantSignCalls int
+		wantRenewals  int
 		wantErr       bool
 	}{
 		{
@@ -239,6 +269,78 @@ func TestCertificateResourceApplyCertificateUpdate(t *testing.T) {
 			wantSignCalls: 1,
 			wantErr:       true,
 		},
+		{
+			name: "outside renewal window keeps certificate",
+			plan: certificateResourceModel{
+				CSR:         types.StringValue("csr"),
+				RenewBefore: types.StringValue("30m"),
+			},
+			state: certificateResourceModel{
+				CSR:  types.StringValue("csr"),
+				Cert: types.StringValue(validCert),
+			},
+			client:   &fakeCertificateClient{},
+			wantCert: validCert,
+		},
+		{
+			name: "renewal window with private key renews",
+			plan: certificateResourceModel{
+				CSR:           types.StringValue("csr"),
+				PrivateKeyPEM: types.StringValue(validKey),
+				RenewBefore:   types.StringValue("2h"),
+			},
+			state: certificateResourceModel{
+				CSR:  types.StringValue("csr"),
+				Cert: types.StringValue(validCert),
+			},
+			client:       &fakeCertificateClient{renewPEM: "renewed-cert"},
+			wantCert:     "renewed-cert",
+			wantRenewals: 1,
+		},
+		{
+			name: "renewal window without private key re-signs",
+			plan: certificateResourceModel{
+				CSR:                  types.StringValue("csr"),
+				MinRemainingFraction: types.Float64Value(0.6),
+			},
+			state: certificateResourceModel{
+				CSR:  types.StringValue("csr"),
+				Cert: types.StringValue(validCert),
+			},
+			client:        &fakeCertificateClient{signPEM: "resigned-cert"},
+			wantCert:      "resigned-cert",
+			wantSignCalls: 1,
+		},
+		{
+			name: "expired certificate re-signs",
+			plan: certificateResourceModel{
+				CSR:           types.StringValue("csr"),
+				PrivateKeyPEM: types.StringValue(expiredKey),
+			},
+			state: certificateResourceModel{
+				CSR:  types.StringValue("csr"),
+				Cert: types.StringValue(expiredCert),
+			},
+			client:        &fakeCertificateClient{signPEM: "fresh-cert"},
+			wantCert:      "fresh-cert",
+			wantSignCalls: 1,
+		},
+		{
+			name: "renew failure reports diagnostics",
+			plan: certificateResourceModel{
+				CSR:           types.StringValue("csr"),
+				PrivateKeyPEM: types.StringValue(validKey),
+				RenewBefore:   types.StringValue("2h"),
+			},
+			state: certificateResourceModel{
+				CSR:  types.StringValue("csr"),
+				Cert: types.StringValue(validCert),
+			},
+			client:       &fakeCertificateClient{renewErr: fmt.Errorf("boom")},
+			wantNullCert: true,
+			wantRenewals: 1,
+			wantErr:      true,
+		},
 	}
 
 	for _, tc := range tests {
@@ -255,6 +357,9 @@ func TestCertificateResourceApplyCertificateUpdate(t *testing.T) {
 			if tc.client != nil && tc.client.signCalls != tc.wantSignCalls {
 				t.Fatalf("expected %d sign calls got %d", tc.wantSignCalls, tc.client.signCalls)
 			}
+			if tc.client != nil && tc.client.renewCalls != tc.wantRenewals {
+				t.Fatalf("expected %d renewals got %d", tc.wantRenewals, tc.client.renewCalls)
+			}
 			if tc.wantNullCert {
 				if !updated.Cert.IsNull() {
 					t.Fatalf("expected certificate to be null")
@@ -264,11 +369,617 @@ func TestCertificateResourceApplyCertificateUpdate(t *testing.T) {
 			if updated.Cert.ValueString() != tc.wantCert {
 				t.Fatalf("expected certificate %q got %q", tc.wantCert, updated.Cert.ValueString())
 			}
+			if tc.wantSignCalls > 0 && updated.FullChainPEM.ValueString() != tc.wantCert+"\nca-cert\n" {
+				t.Fatalf("unexpected full chain %q", updated.FullChainPEM.ValueString())
+			}
+		})
+	}
+}
+
+func TestCheckCertificateChain(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	leafPEM, caPEM := testCertificateChain(t, "svc.test")
+	_, otherCAPEM := testCertificateChain(t, "other.test")
+	leaf, err := parseCertificate(leafPEM)
+	if err != nil {
+		t.Fatalf("parse leaf: %v", err)
+	}
+	chainList := func(pems ...string) types.List {
+		l, _ := types.ListValueFrom(ctx, types.StringType, pems)
+		return l
+	}
+
+	tests := []struct {
+		name      string
+		stored    types.List
+		remotePEM string
+		wantErr   bool
+	}{
+		{name: "no stored chain", stored: types.ListNull(types.StringType), remotePEM: leafPEM},
+		{name: "valid chain", stored: chainList(leafPEM, caPEM), remotePEM: leafPEM},
+		{name: "remote bundle matches", stored: chainList(leafPEM, caPEM), remotePEM: leafPEM + caPEM},
+		{name: "chain without leaf", stored: chainList(caPEM), remotePEM: leafPEM, wantErr: true},
+		{name: "wrong intermediate", stored: chainList(leafPEM, otherCAPEM), remotePEM: leafPEM, wantErr: true},
+		{name: "remote intermediate changed", stored: chainList(leafPEM, caPEM), remotePEM: leafPEM + otherCAPEM, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkCertificateChain(ctx, leaf, tc.stored, tc.remotePEM)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error=%t got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestCertificateResourceRevokeCertificate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cert, key := testCertificateWithKey(t, 4660, "svc.test")
+
+	tests := []struct {
+		name        string
+		data        certificateResourceModel
+		client      *fakeCertificateClient
+		wantRevoked bool
+		wantMTLS    bool
+		wantErr     bool
+	}{
+		{
+			name:   "disabled only forgets",
+			data:   certificateResourceModel{Cert: types.StringValue(cert)},
+			client: &fakeCertificateClient{},
+		},
+		{
+			name: "revokes with reason",
+			data: certificateResourceModel{
+				Cert:                 types.StringValue(cert),
+				RevokeOnDestroy:      types.BoolValue(true),
+				RevocationReason:     types.StringValue("decommissioned"),
+				RevocationReasonCode: types.Int64Value(5),
+			},
+			client:      &fakeCertificateClient{},
+			wantRevoked: true,
+		},
+		{
+			name: "private key uses mTLS",
+			data: certificateResourceModel{
+				Cert:            types.StringValue(cert),
+				PrivateKeyPEM:   types.StringValue(key),
+				RevokeOnDestroy: types.BoolValue(true),
+			},
+			client:      &fakeCertificateClient{},
+			wantRevoked: true,
+			wantMTLS:    true,
+		},
+		{
+			name: "mismatched private key",
+			data: certificateResourceModel{
+				Cert:            types.StringValue(cert),
+				PrivateKeyPEM:   types.StringValue("not-a-key"),
+				RevokeOnDestroy: types.BoolValue(true),
+			},
+			client:  &fakeCertificateClient{},
+			wantErr: true,
+		},
+		{
+			name: "revoke failure keeps state",
+			data: certificateResourceModel{
+				Cert:            types.StringValue(cert),
+				RevokeOnDestroy: types.BoolValue(true),
+			},
+			client:      &fakeCertificateClient{revokeErr: fmt.Errorf("boom")},
+			wantRevoked: true,
+			wantErr:     true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &certificateResource{client: tc.client}
+			diags := r.revokeCertificate(ctx, tc.data)
+			if diags.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%t got %v", tc.wantErr, diags)
+			}
+			if got := len(tc.client.revoked) == 1; got != tc.wantRevoked {
+				t.Fatalf("expected revoked=%t got %d calls", tc.wantRevoked, len(tc.client.revoked))
+			}
+			if !tc.wantRevoked {
+				return
+			}
+			req := tc.client.revoked[0]
+			if req.Serial != "4660" {
+				t.Fatalf("expected decimal serial 4660 got %s", req.Serial)
+			}
+			if req.ReasonCode != int(tc.data.RevocationReasonCode.ValueInt64()) || req.Reason != tc.data.RevocationReason.ValueString() {
+				t.Fatalf("unexpected reason: %#v", req)
+			}
+			if (req.ClientCertificate != nil) != tc.wantMTLS {
+				t.Fatalf("expected mTLS=%t", tc.wantMTLS)
+			}
+		})
+	}
+}
+
+func TestRenewalDue(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+	cert := &x509.Certificate{
+		NotBefore: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:  time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name        string
+		now         time.Time
+		renewBefore time.Duration
+		fraction    float64
+		want        bool
+	}{
+		{name: "no settings", now: now},
+		{name: "expired without settings", now: cert.NotAfter.Add(time.Second), want: true},
+		{name: "outside renew_before", now: now, renewBefore: 24 * time.Hour},
+		{name: "inside renew_before", now: now, renewBefore: 240 * time.Hour, want: true},
+		{name: "above fraction", now: now, fraction: 0.2},
+		{name: "below fraction", now: now, fraction: 0.3, want: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := renewalDue(cert, tc.renewBefore, tc.fraction, tc.now); got != tc.want {
+				t.Fatalf("renewalDue() = %t, want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRenewalSettings(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		data            certificateResourceModel
+		wantRenewBefore time.Duration
+		wantFraction    float64
+		wantErr         bool
+	}{
+		{name: "unset", data: certificateResourceModel{}},
+		{
+			name:            "valid",
+			data:            certificateResourceModel{RenewBefore: types.StringValue("720h"), MinRemainingFraction: types.Float64Value(0.25)},
+			wantRenewBefore: 720 * time.Hour,
+			wantFraction:    0.25,
+		},
+		{name: "invalid duration", data: certificateResourceModel{RenewBefore: types.StringValue("30 days")}, wantErr: true},
+		{name: "negative duration", data: certificateResourceModel{RenewBefore: types.StringValue("-1h")}, wantErr: true},
+		{name: "fraction too large", data: certificateResourceModel{MinRemainingFraction: types.Float64Value(1)}, wantErr: true},
+		{name: "fraction zero", data: certificateResourceModel{MinRemainingFraction: types.Float64Value(0)}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			renewBefore, fraction, diags := renewalSettings(tc.data)
+			if diags.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%t got %v", tc.wantErr, diags)
+			}
+			if tc.wantErr {
+				return
+			}
+			if renewBefore != tc.wantRenewBefore || fraction != tc.wantFraction {
+				t.Fatalf("unexpected settings %s %g", renewBefore, fraction)
+			}
+		})
+	}
+}
+
+func TestNewCertificateDetails(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	spiffe, _ := url.Parse("spiffe://example.com/svc")
+	notBefore := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tmpl := &x509.Certificate{
+		SerialNumber:   big.NewInt(0xabcdef),
+		Subject:        pkix.Name{CommonName: "svc.example.com", Organization: []string{"Example"}},
+		NotBefore:      notBefore,
+		NotAfter:       notBefore.Add(24 * time.Hour),
+		DNSNames:       []string{"svc.example.com", "alt.example.com"},
+		IPAddresses:    []net.IP{net.ParseIP("10.0.0.1")},
+		URIs:           []*url.URL{spiffe},
+		EmailAddresses: []string{"ops@example.com"},
+		KeyUsage:       x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, priv.Public(), priv)
+	if err != nil {
+		t.Fatalf("create cert: %v", err)
+	}
+	certPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+
+	details, diags := newCertificateDetails(ctx, certPEM)
+	if diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	sum := sha256.Sum256(der)
+	for name, got := range map[string]types.String{
+		"serial_number":        details.SerialNumber,
+		"subject":              details.Subject,
+		"issuer":               details.Issuer,
+		"not_before":           details.NotBefore,
+		"not_after":            details.NotAfter,
+		"sha256_fingerprint":   details.SHA256Fingerprint,
+		"public_key_algorithm": details.PublicKeyAlgorithm,
+	} {
+		want := map[string]string{
+			"serial_number":        "abcdef",
+			"subject":              "CN=svc.example.com,O=Example",
+			"issuer":               "CN=svc.example.com,O=Example",
+			"not_before":           "2025-01-01T00:00:00Z",
+			"not_after":            "2025-01-02T00:00:00Z",
+			"sha256_fingerprint":   hex.EncodeToString(sum[:]),
+			"public_key_algorithm": "Ed25519",
+		}[name]
+		if got.ValueString() != want {
+			t.Fatalf("%s: expected %q got %q", name, want, got.ValueString())
+		}
+	}
+	for name, tc := range map[string]struct {
+		got  types.List
+		want []string
+	}{
+		"dns_names":       {details.DNSNames, []string{"svc.example.com", "alt.example.com"}},
+		"ip_addresses":    {details.IPAddresses, []string{"10.0.0.1"}},
+		"uris":            {details.URIs, []string{"spiffe://example.com/svc"}},
+		"email_addresses": {details.EmailAddresses, []string{"ops@example.com"}},
+		"key_usages":      {details.KeyUsages, []string{"digital_signature", "key_encipherment"}},
+		"ext_key_usages":  {details.ExtKeyUsages, []string{"server_auth", "client_auth"}},
+	} {
+		var got []string
+		tc.got.ElementsAs(ctx, &got, false)
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Fatalf("%s: expected %v got %v", name, tc.want, got)
+		}
+	}
+
+	empty, _ := newCertificateDetails(ctx, "")
+	if !empty.SerialNumber.IsNull() || !empty.DNSNames.IsNull() {
+		t.Fatalf("expected null details without a certificate")
+	}
+}
+
+func TestCertificateResourceSchemaMatchesModel(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var resp resource.SchemaResponse
+	NewCertificateResource().Schema(ctx, resource.SchemaRequest{}, &resp)
+	cert := testCertificate(t, 1, "svc.test")
+
+	data := certificateResourceModel{CSR: types.StringValue("csr")}
+	if diags := data.setSignResult(ctx, &client.SignResult{Certificate: cert}); diags.HasError() {
+		t.Fatalf("set sign result: %v", diags)
+	}
+	if diags := data.refreshDetails(ctx); diags.HasError() {
+		t.Fatalf("refresh details: %v", diags)
+	}
+	state := tfsdk.State{Schema: resp.Schema}
+	if diags := state.Set(ctx, &data); diags.HasError() {
+		t.Fatalf("state does not match schema: %v", diags)
+	}
+	var got certificateResourceModel
+	if diags := state.Get(ctx, &got); diags.HasError() {
+		t.Fatalf("read state: %v", diags)
+	}
+	if got.SerialNumber.ValueString() != "1" {
+		t.Fatalf("expected serial 1 got %q", got.SerialNumber.ValueString())
+	}
+}
+
+func TestValidateCertificateRequest(t *testing.T) {
+	t.Parallel()
+
+	dnsNames, _ := types.ListValueFrom(context.Background(), types.StringType, []string{"svc.test"})
+	empty := certificateResourceModel{
+		CSR:           types.StringNull(),
+		PrivateKeyPEM: types.StringNull(),
+		certificateDetailsModel: certificateDetailsModel{
+			Subject:  types.StringNull(),
+			DNSNames: types.ListNull(types.StringType),
+		},
+	}
+	with := func(f func(*certificateResourceModel)) certificateResourceModel {
+		m := empty
+		f(&m)
+		return m
+	}
+
+	tests := []struct {
+		name    string
+		data    certificateResourceModel
+		wantErr bool
+	}{
+		{name: "csr", data: with(func(m *certificateResourceModel) { m.CSR = types.StringValue("csr") })},
+		{name: "csr with key for mTLS", data: with(func(m *certificateResourceModel) {
+			m.CSR = types.StringValue("csr")
+			m.PrivateKeyPEM = types.StringValue("key")
+		})},
+		{name: "key and subject", data: with(func(m *certificateResourceModel) {
+			m.PrivateKeyPEM = types.StringValue("key")
+			m.Subject = types.StringValue("CN=svc.test")
+		})},
+		{name: "key and dns names", data: with(func(m *certificateResourceModel) {
+			m.PrivateKeyPEM = types.StringValue("key")
+			m.DNSNames = dnsNames
+		})},
+		{name: "unknown csr", data: with(func(m *certificateResourceModel) { m.CSR = types.StringUnknown() })},
+		{name: "nothing", data: empty, wantErr: true},
+		{name: "key without names", data: with(func(m *certificateResourceModel) { m.PrivateKeyPEM = types.StringValue("key") }), wantErr: true},
+		{name: "subject without key", data: with(func(m *certificateResourceModel) { m.Subject = types.StringValue("CN=svc.test") }), wantErr: true},
+		{name: "csr and subject", data: with(func(m *certificateResourceModel) {
+			m.CSR = types.StringValue("csr")
+			m.Subject = types.StringValue("CN=svc.test")
+		}), wantErr: true},
+		{name: "invalid subject", data: with(func(m *certificateResourceModel) {
+			m.PrivateKeyPEM = types.StringValue("key")
+			m.Subject = types.StringValue("svc.test")
+		}), wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if diags := validateCertificateRequest(tc.data); diags.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%t got %v", tc.wantErr, diags)
+			}
+		})
+	}
+}
+
+func TestCertificateResourceModifyPlan(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var schemaResp resource.SchemaResponse
+	NewCertificateResource().Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+	cert := testCertificate(t, 1, "svc.test")
+
+	stored := certificateResourceModel{CSR: types.StringValue("csr")}
+	if diags := stored.setSignResult(ctx, &client.SignResult{Certificate: cert}); diags.HasError() {
+		t.Fatalf("set sign result: %v", diags)
+	}
+	if diags := stored.refreshDetails(ctx); diags.HasError() {
+		t.Fatalf("refresh details: %v", diags)
+	}
+
+	tests := []struct {
+		name        string
+		csr         string
+		wantUnknown bool
+	}{
+		{name: "unchanged request keeps certificate", csr: "csr"},
+		{name: "new csr plans new certificate", csr: "new-csr", wantUnknown: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			planned := stored
+			planned.CSR = types.StringValue(tc.csr)
+			state := tfsdk.State{Schema: schemaResp.Schema}
+			plan := tfsdk.Plan{Schema: schemaResp.Schema}
+			configured := tfsdk.Plan{Schema: schemaResp.Schema}
+			state.Set(ctx, &stored)
+			plan.Set(ctx, &planned)
+			configured.Set(ctx, &certificateResourceModel{
+				CSR:                     types.StringValue(tc.csr),
+				CertificateChain:        types.ListNull(types.StringType),
+				certificateDetailsModel: nullCertificateDetails(),
+			})
+
+			resp := resource.ModifyPlanResponse{Plan: plan}
+			r := &certificateResource{}
+			r.ModifyPlan(ctx, resource.ModifyPlanRequest{
+				State:  state,
+				Plan:   plan,
+				Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: configured.Raw},
+			}, &resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("modify plan: %v", resp.Diagnostics)
+			}
+			var got certificateResourceModel
+			resp.Plan.Get(ctx, &got)
+			if got.Cert.IsUnknown() != tc.wantUnknown || got.SerialNumber.IsUnknown() != tc.wantUnknown {
+				t.Fatalf("expected unknown=%t got certificate %v serial %v", tc.wantUnknown, got.Cert, got.SerialNumber)
+			}
+		})
+	}
+}
+
+func TestCertificateResourceImportState(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	leaf, ca := testCertificateChain(t, "imported.test")
+	fake := &fakeCertificateClient{t: t, serial: "65", found: true, pem: leaf + ca}
+	r := &certificateResource{client: fake}
+	var schemaResp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+
+	resp := resource.ImportStateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	r.ImportState(ctx, resource.ImportStateRequest{ID: "0x00:65"}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", resp.Diagnostics)
+	}
+	var got certificateResourceModel
+	if diags := resp.State.Get(ctx, &got); diags.HasError() {
+		t.Fatalf("get state: %v", diags)
+	}
+	if got.Cert.ValueString() != leaf || got.CACertificate.ValueString() != ca || len(got.CertificateChain.Elements()) != 2 {
+		t.Fatalf("unexpected imported certificate: %+v", got)
+	}
+	if got.Subject.ValueString() != "CN=imported.test" || !got.CSR.IsNull() {
+		t.Fatalf("unexpected imported attributes: %+v", got)
+	}
+	keep, diags := r.shouldKeepCertificate(ctx, &got)
+	if !keep || diags.HasError() {
+		t.Fatalf("imported certificate must pass the read checks: %v", diags)
+	}
+
+	missing := &certificateResource{client: &fakeCertificateClient{}}
+	resp = resource.ImportStateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	missing.ImportState(ctx, resource.ImportStateRequest{ID: "66"}, &resp)
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected an error for an unknown serial")
+	}
+}
+
+func TestImportedCertificateMatches(t *testing.T) {
+	t.Parallel()
+
+	cert, keyPEM := testCertificateWithKey(t, 12, "adopt.test")
+	key, err := parsePrivateKeyPEM(keyPEM)
+	if err != nil {
+		t.Fatalf("parse key: %v", err)
+	}
+	csr, err := buildCSR(key, csrRequest{DNSNames: []string{"adopt.test"}})
+	if err != nil {
+		t.Fatalf("build csr: %v", err)
+	}
+	otherKey, err := generatePrivateKey("ED25519", 0, "")
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	otherCSR, err := buildCSR(otherKey, csrRequest{DNSNames: []string{"adopt.test"}})
+	if err != nil {
+		t.Fatalf("build csr: %v", err)
+	}
+	imported := certificateResourceModel{CSR: types.StringNull(), PrivateKeyPEM: types.StringNull(), Cert: types.StringValue(cert)}
+
+	tests := []struct {
+		name  string
+		plan  certificateResourceModel
+		state certificateResourceModel
+		want  bool
+	}{
+		{name: "matching csr", plan: certificateResourceModel{CSR: types.StringValue(csr)}, state: imported, want: true},
+		{name: "matching key", plan: certificateResourceModel{CSR: types.StringNull(), PrivateKeyPEM: types.StringValue(keyPEM)}, state: imported, want: true},
+		{name: "other key", plan: certificateResourceModel{CSR: types.StringValue(otherCSR)}, state: imported},
+		{
+			name:  "not imported",
+			plan:  certificateResourceModel{CSR: types.StringValue(csr)},
+			state: certificateResourceModel{CSR: types.StringValue(otherCSR), Cert: types.StringValue(cert)},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := importedCertificateMatches(tc.plan, tc.state); got != tc.want {
+				t.Fatalf("expected %t got %t", tc.want, got)
+			}
+			if got := needsCertificateRotation(tc.plan, tc.state); got == tc.want {
+				t.Fatalf("expected rotation=%t", !tc.want)
+			}
+		})
+	}
+}
+
+func TestCertificateResourceGeneratedRequest(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	key, err := generatePrivateKey("ECDSA", 0, "")
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	keyPEM, err := encodePrivateKeyPEM(key)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	dnsNames, _ := types.ListValueFrom(ctx, types.StringType, []string{"svc.test", "alt.test"})
+	plan := certificateResourceModel{
+		CSR:           types.StringNull(),
+		PrivateKeyPEM: types.StringValue(keyPEM),
+		certificateDetailsModel: certificateDetailsModel{
+			Subject:  types.StringValue("CN=svc.test"),
+			DNSNames: dnsNames,
+		},
+	}
+
+	fake := &fakeCertificateClient{signPEM: "generated-cert"}
+	r := &certificateResource{client: fake}
+	state := plan
+	state.PrivateKeyPEM = types.StringValue("old-key")
+	if _, diags := r.applyCertificateUpdate(ctx, plan, state); diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+	if fake.signCalls != 1 {
+		t.Fatalf("expected a new certificate for a new key, got %d sign calls", fake.signCalls)
+	}
+	csr, err := parseCertificateRequestPEM(fake.signCSR)
+	if err != nil {
+		t.Fatalf("parse generated csr: %v", err)
+	}
+	if csr.Subject.CommonName != "svc.test" || len(csr.DNSNames) != 2 {
+		t.Fatalf("unexpected csr: %+v", csr)
+	}
+
+	unchanged := plan
+	unchanged.Subject = types.StringUnknown()
+	if needsCertificateRotation(unchanged, plan) {
+		t.Fatal("an unconfigured subject must not force a new certificate")
+	}
+	renamed := plan
+	renamed.Subject = types.StringValue("CN=other.test")
+	if !needsCertificateRotation(renamed, plan) {
+		t.Fatal("a new subject must force a new certificate")
+	}
+}
+
+func TestValidateRevocationReasonCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		value   types.Int64
+		wantErr bool
+	}{
+		{name: "unset", value: types.Int64Null()},
+		{name: "unspecified", value: types.Int64Value(0)},
+		{name: "key compromise", value: types.Int64Value(1)},
+		{name: "aa compromise", value: types.Int64Value(10)},
+		{name: "unassigned", value: types.Int64Value(7), wantErr: true},
+		{name: "negative", value: types.Int64Value(-1), wantErr: true},
+		{name: "too large", value: types.Int64Value(11), wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			diags := validateRevocationReasonCode(tc.value)
+			if diags.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%t got %v", tc.wantErr, diags)
+			}
 		})
 	}
 }
 
 func testCertificate(t *testing.T, serial int64, cn string) string {
+	t.Helper()
+	cert, _ := testCertificateWithKey(t, serial, cn)
+	return cert
+}
+
+// testCertificateWithKey returns a self-signed certificate and its PKCS#8 key.
+func testCertificateWithKey(t *testing.T, serial int64, cn string) (string, string) {
+	t.Helper()
+	return testCertificateValidity(t, serial, cn, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+}
+
+func testCertificateValidity(t *testing.T, serial int64, cn string, notBefore, notAfter time.Time) (string, string) {
 	t.Helper()
 	_, priv, err := ed25519.GenerateKey(rand.Reader)
 	if err != nil {
@@ -277,8 +988,8 @@ func testCertificate(t *testing.T, serial int64, cn string) string {
 	tmpl := &x509.Certificate{
 		SerialNumber:          big.NewInt(serial),
 		Subject:               pkix.Name{CommonName: cn},
-		NotBefore:             time.Now().Add(-time.Hour),
-		NotAfter:              time.Now().Add(time.Hour),
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
 		BasicConstraintsValid: true,
 		KeyUsage:              x509.KeyUsageDigitalSignature,
 	}
@@ -286,7 +997,54 @@ func testCertificate(t *testing.T, serial int64, cn string) string {
 	if err != nil {
 		t.Fatalf("create cert: %v", err)
 	}
-	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	keyDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+}
+
+// testCertificateChain returns a leaf certificate and the CA that issued it.
+func testCertificateChain(t *testing.T, cn string) (string, string) {
+	t.Helper()
+	_, caKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(100),
+		Subject:               pkix.Name{CommonName: cn + " CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, caKey.Public(), caKey)
+	if err != nil {
+		t.Fatalf("create ca: %v", err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatalf("parse ca: %v", err)
+	}
+	_, leafKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	leafTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(101),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTmpl, ca, leafKey.Public(), caKey)
+	if err != nil {
+		t.Fatalf("create leaf: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leafDER})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}))
 }
 
 func serialFromCert(t *testing.T, pemData string) string {
@@ -0,0 +1,188 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ resource.Resource                   = &csrResource{}
+	_ resource.ResourceWithValidateConfig = &csrResource{}
+)
+
+// NewCSRResource returns the stepca_csr resource, which builds and signs a
+// certificate signing request locally.
+func NewCSRResource() resource.Resource { return &csrResource{} }
+
+type csrResource struct{}
+
+type csrResourceModel struct {
+	PrivateKeyPEM  types.String `tfsdk:"private_key_pem"`
+	Subject        types.String `tfsdk:"subject"`
+	DNSNames       types.List   `tfsdk:"dns_names"`
+	IPAddresses    types.List   `tfsdk:"ip_addresses"`
+	URIs           types.List   `tfsdk:"uris"`
+	EmailAddresses types.List   `tfsdk:"email_addresses"`
+	KeyUsages      types.List   `tfsdk:"key_usages"`
+	ExtKeyUsages   types.List   `tfsdk:"ext_key_usages"`
+	CSRPEM         types.String `tfsdk:"csr_pem"`
+}
+
+func (r *csrResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = "stepca_csr"
+}
+
+func (r *csrResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	list := func(description string) schema.Attribute {
+		return schema.ListAttribute{
+			Optional:      true,
+			ElementType:   types.StringType,
+			Description:   description,
+			PlanModifiers: []planmodifier.List{listplanmodifier.RequiresReplace()},
+		}
+	}
+	resp.Schema = schema.Schema{
+		Description: "Builds a PEM encoded certificate signing request signed with the given private key.",
+		Attributes: map[string]schema.Attribute{
+			"private_key_pem": schema.StringAttribute{
+				Required:      true,
+				Sensitive:     true,
+				Description:   "PEM encoded private key used to sign the request.",
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"subject": schema.StringAttribute{
+				Optional:      true,
+				Description:   "Subject distinguished name such as \"CN=svc.example.com,O=Example\".",
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"dns_names":       list("DNS subject alternative names."),
+			"ip_addresses":    list("IP address subject alternative names."),
+			"uris":            list("URI subject alternative names."),
+			"email_addresses": list("Email subject alternative names."),
+			"key_usages":      list("Requested key usages, for example digital_signature."),
+			"ext_key_usages":  list("Requested extended key usages, for example server_auth."),
+			"csr_pem": schema.StringAttribute{
+				Computed:    true,
+				Description: "The PEM encoded certificate signing request.",
+			},
+		},
+	}
+}
+
+func (r *csrResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data csrResourceModel
+	diags := req.Config.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !data.Subject.IsUnknown() {
+		if _, err := parseDistinguishedName(data.Subject.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("subject"), "invalid subject", err.Error())
+		}
+	}
+}
+
+func (r *csrResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data csrResourceModel
+	diags := req.Plan.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	csrPEM, buildDiags := data.build(ctx)
+	resp.Diagnostics.Append(buildDiags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.CSRPEM = types.StringValue(csrPEM)
+
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
+
+// build signs a CSR for the configured subject and extensions.
+func (m csrResourceModel) build(ctx context.Context) (string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	subject, err := parseDistinguishedName(m.Subject.ValueString())
+	if err != nil {
+		diags.AddAttributeError(path.Root("subject"), "invalid subject", err.Error())
+		return "", diags
+	}
+	req := csrRequest{Subject: subject}
+	for _, l := range []struct {
+		value  types.List
+		target *[]string
+	}{
+		{m.DNSNames, &req.DNSNames},
+		{m.IPAddresses, &req.IPAddresses},
+		{m.URIs, &req.URIs},
+		{m.EmailAddresses, &req.EmailAddresses},
+		{m.KeyUsages, &req.KeyUsages},
+		{m.ExtKeyUsages, &req.ExtKeyUsages},
+	} {
+		diags.Append(stringList(ctx, l.value, l.target)...)
+	}
+	if diags.HasError() {
+		return "", diags
+	}
+
+	key, err := parsePrivateKeyPEM(m.PrivateKeyPEM.ValueString())
+	if err != nil {
+		diags.AddAttributeError(path.Root("private_key_pem"), "invalid private key", err.Error())
+		return "", diags
+	}
+	csrPEM, err := buildCSR(key, req)
+	if err != nil {
+		diags.AddError("CSR generation failed", err.Error())
+		return "", diags
+	}
+	return csrPEM, diags
+}
+
+// Read keeps the stored CSR; it only exists in Terraform state.
+func (r *csrResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data csrResourceModel
+	diags := req.State.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Update is never called with changes because every argument forces a new CSR.
+func (r *csrResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data csrResourceModel
+	diags := req.State.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
+
+func (r *csrResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	resp.State.RemoveResource(ctx)
+}
+
+// stringList copies a list of strings into target, leaving it nil for null
+// or unknown lists.
+func stringList(ctx context.Context, l types.List, target *[]string) diag.Diagnostics {
+	if l.IsNull() || l.IsUnknown() {
+		return nil
+	}
+	return l.ElementsAs(ctx, target, false)
+}
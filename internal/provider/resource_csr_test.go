@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestCSRResourceBuild(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	key, err := generatePrivateKey("ED25519", 0, "")
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	keyPEM, err := encodePrivateKeyPEM(key)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	list := func(values ...string) types.List {
+		l, _ := types.ListValueFrom(ctx, types.StringType, values)
+		return l
+	}
+
+	tests := []struct {
+		name    string
+		data    csrResourceModel
+		wantCN  string
+		wantErr bool
+	}{
+		{
+			name: "subject and sans",
+			data: csrResourceModel{
+				PrivateKeyPEM: types.StringValue(keyPEM),
+				Subject:       types.StringValue("CN=svc.example.com,O=Example"),
+				DNSNames:      list("svc.example.com"),
+				ExtKeyUsages:  list("server_auth"),
+			},
+			wantCN: "svc.example.com",
+		},
+		{
+			name: "sans only",
+			data: csrResourceModel{
+				PrivateKeyPEM: types.StringValue(keyPEM),
+				Subject:       types.StringNull(),
+				IPAddresses:   list("10.0.0.1"),
+			},
+		},
+		{
+			name: "invalid subject",
+			data: csrResourceModel{
+				PrivateKeyPEM: types.StringValue(keyPEM),
+				Subject:       types.StringValue("common name"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid key",
+			data: csrResourceModel{
+				PrivateKeyPEM: types.StringValue("not-a-key"),
+				DNSNames:      list("svc.example.com"),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			csrPEM, diags := tc.data.build(ctx)
+			if diags.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%t got %v", tc.wantErr, diags)
+			}
+			if tc.wantErr {
+				return
+			}
+			block, _ := pem.Decode([]byte(csrPEM))
+			if block == nil {
+				t.Fatalf("invalid PEM: %q", csrPEM)
+			}
+			csr, err := x509.ParseCertificateRequest(block.Bytes)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if csr.Subject.CommonName != tc.wantCN {
+				t.Fatalf("expected CN %q got %q", tc.wantCN, csr.Subject.CommonName)
+			}
+		})
+	}
+}
@@ -0,0 +1,341 @@
+package provider
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var (
+	_ resource.Resource                   = &privateKeyResource{}
+	_ resource.ResourceWithValidateConfig = &privateKeyResource{}
+	_ resource.ResourceWithImportState    = &privateKeyResource{}
+)
+
+// NewPrivateKeyResource returns the stepca_private_key resource. Keys are
+// generated locally and never sent to the CA.
+func NewPrivateKeyResource() resource.Resource { return &privateKeyResource{} }
+
+type privateKeyResource struct{}
+
+type privateKeyResourceModel struct {
+	Algorithm            types.String `tfsdk:"algorithm"`
+	RSABits              types.Int64  `tfsdk:"rsa_bits"`
+	ECDSACurve           types.String `tfsdk:"ecdsa_curve"`
+	PrivateKeyPEM        types.String `tfsdk:"private_key_pem"`
+	PublicKeyPEM         types.String `tfsdk:"public_key_pem"`
+	PublicKeyFingerprint types.String `tfsdk:"public_key_fingerprint_sha256"`
+}
+
+func (r *privateKeyResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = "stepca_private_key"
+}
+
+func (r *privateKeyResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Generates a private key for use with stepca_csr or stepca_certificate.",
+		Attributes: map[string]schema.Attribute{
+			"algorithm": schema.StringAttribute{
+				Required:      true,
+				Description:   "Key algorithm: RSA, ECDSA or ED25519.",
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"rsa_bits": schema.Int64Attribute{
+				Optional:      true,
+				Computed:      true,
+				Description:   "RSA key size: 2048 (default), 3072 or 4096.",
+				PlanModifiers: []planmodifier.Int64{rsaBitsPlanModifier{}},
+			},
+			"ecdsa_curve": schema.StringAttribute{
+				Optional:      true,
+				Computed:      true,
+				Description:   "ECDSA curve: P256 (default) or P384.",
+				PlanModifiers: []planmodifier.String{ecdsaCurvePlanModifier{}},
+			},
+			"private_key_pem": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The private key in PKCS#8 PEM format.",
+			},
+			"public_key_pem": schema.StringAttribute{
+				Computed:    true,
+				Description: "The public key in PKIX PEM format.",
+			},
+			"public_key_fingerprint_sha256": schema.StringAttribute{
+				Computed:    true,
+				Description: "Lowercase hex SHA-256 digest of the DER encoded public key.",
+			},
+		},
+	}
+}
+
+func (r *privateKeyResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data privateKeyResourceModel
+	diags := req.Config.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(validatePrivateKeyConfig(data)...)
+}
+
+func validatePrivateKeyConfig(data privateKeyResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if data.Algorithm.IsUnknown() {
+		return diags
+	}
+	algorithm := data.Algorithm.ValueString()
+	switch algorithm {
+	case keyAlgorithmRSA, keyAlgorithmECDSA, keyAlgorithmEd25519:
+	default:
+		diags.AddAttributeError(path.Root("algorithm"), "invalid key algorithm",
+			fmt.Sprintf("algorithm must be RSA, ECDSA or ED25519, got %q", algorithm))
+		return diags
+	}
+	if !data.RSABits.IsNull() && !data.RSABits.IsUnknown() {
+		if algorithm != keyAlgorithmRSA {
+			diags.AddAttributeError(path.Root("rsa_bits"), "invalid key configuration", "rsa_bits can only be set for RSA keys")
+		} else if bits := data.RSABits.ValueInt64(); bits != 2048 && bits != 3072 && bits != 4096 {
+			diags.AddAttributeError(path.Root("rsa_bits"), "invalid key configuration",
+				fmt.Sprintf("rsa_bits must be 2048, 3072 or 4096, got %d", bits))
+		}
+	}
+	if !data.ECDSACurve.IsNull() && !data.ECDSACurve.IsUnknown() {
+		if algorithm != keyAlgorithmECDSA {
+			diags.AddAttributeError(path.Root("ecdsa_curve"), "invalid key configuration", "ecdsa_curve can only be set for ECDSA keys")
+		} else if curve := data.ECDSACurve.ValueString(); curve != "P256" && curve != "P384" {
+			diags.AddAttributeError(path.Root("ecdsa_curve"), "invalid key configuration",
+				fmt.Sprintf("ecdsa_curve must be P256 or P384, got %q", curve))
+		}
+	}
+	return diags
+}
+
+func (r *privateKeyResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data privateKeyResourceModel
+	diags := req.Plan.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	key, err := generatePrivateKey(data.Algorithm.ValueString(), int(data.RSABits.ValueInt64()), data.ECDSACurve.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("key generation failed", err.Error())
+		return
+	}
+	privPEM, err := encodePrivateKeyPEM(key)
+	if err != nil {
+		resp.Diagnostics.AddError("key generation failed", err.Error())
+		return
+	}
+	pubPEM, fingerprint, err := publicKeyPEM(key)
+	if err != nil {
+		resp.Diagnostics.AddError("key generation failed", err.Error())
+		return
+	}
+	data.PrivateKeyPEM = types.StringValue(privPEM)
+	data.PublicKeyPEM = types.StringValue(pubPEM)
+	data.PublicKeyFingerprint = types.StringValue(fingerprint)
+
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
+
+// ImportState adopts an existing key from the PEM file the import ID points
+// to. The ID is a path rather than the key itself so the key does not end up
+// in shell history or logs. The algorithm, size and curve are derived from
+// the key.
+func (r *privateKeyResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	b, err := os.ReadFile(req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("invalid import ID", fmt.Sprintf("Expected the path of a PEM encoded private key: %s.", err))
+		return
+	}
+	key, err := parsePrivateKeyPEM(string(b))
+	if err != nil {
+		resp.Diagnostics.AddError("invalid import ID", fmt.Sprintf("Expected the path of a PEM encoded private key: %s.", err))
+		return
+	}
+	data, err := privateKeyModelFromKey(key)
+	if err != nil {
+		resp.Diagnostics.AddError("unsupported private key", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// privateKeyModelFromKey describes key in terms of the resource arguments.
+// Keys the resource could not have generated are rejected.
+func privateKeyModelFromKey(key crypto.Signer) (privateKeyResourceModel, error) {
+	data := privateKeyResourceModel{RSABits: types.Int64Null(), ECDSACurve: types.StringNull()}
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		bits := k.N.BitLen()
+		if bits != 2048 && bits != 3072 && bits != 4096 {
+			return data, fmt.Errorf("RSA keys must have 2048, 3072 or 4096 bits, got %d", bits)
+		}
+		data.Algorithm = types.StringValue(keyAlgorithmRSA)
+		data.RSABits = types.Int64Value(int64(bits))
+	case *ecdsa.PrivateKey:
+		data.Algorithm = types.StringValue(keyAlgorithmECDSA)
+		switch k.Curve {
+		case elliptic.P256():
+			data.ECDSACurve = types.StringValue(defaultECDSACurve)
+		case elliptic.P384():
+			data.ECDSACurve = types.StringValue("P384")
+		default:
+			return data, fmt.Errorf("ECDSA keys must use P256 or P384, got %s", k.Curve.Params().Name)
+		}
+	case ed25519.PrivateKey:
+		data.Algorithm = types.StringValue(keyAlgorithmEd25519)
+	default:
+		return data, fmt.Errorf("unsupported private key type %T", key)
+	}
+	privPEM, err := encodePrivateKeyPEM(key)
+	if err != nil {
+		return data, err
+	}
+	pubPEM, fingerprint, err := publicKeyPEM(key)
+	if err != nil {
+		return data, err
+	}
+	data.PrivateKeyPEM = types.StringValue(privPEM)
+	data.PublicKeyPEM = types.StringValue(pubPEM)
+	data.PublicKeyFingerprint = types.StringValue(fingerprint)
+	return data, nil
+}
+
+// Read keeps the stored key; it only exists in Terraform state.
+func (r *privateKeyResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data privateKeyResourceModel
+	diags := req.State.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
+
+// Update only records rsa_bits or ecdsa_curve switching between unset and
+// their default; every other change forces a new key.
+func (r *privateKeyResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data, plan privateKeyResourceModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.RSABits = plan.RSABits
+	data.ECDSACurve = plan.ECDSACurve
+	diags := resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
+
+// rsaBitsPlanModifier keeps the stored rsa_bits when the configuration omits
+// it and only replaces the key when the size really changes, so an unset
+// rsa_bits and rsa_bits = 2048 describe the same key.
+type rsaBitsPlanModifier struct{}
+
+func (m rsaBitsPlanModifier) Description(ctx context.Context) string {
+	return "Requires replacement when the RSA key size changes; an unset size is 2048."
+}
+
+func (m rsaBitsPlanModifier) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m rsaBitsPlanModifier) PlanModifyInt64(ctx context.Context, req planmodifier.Int64Request, resp *planmodifier.Int64Response) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	if req.State.Raw.IsNull() || !keepsKeyAlgorithm(ctx, req.State, req.Plan) {
+		if req.ConfigValue.IsNull() {
+			resp.PlanValue = types.Int64Null()
+		}
+		return
+	}
+	switch {
+	case req.ConfigValue.IsNull():
+		resp.PlanValue = req.StateValue
+	case req.ConfigValue.IsUnknown():
+		resp.RequiresReplace = true
+	default:
+		resp.RequiresReplace = req.ConfigValue.ValueInt64() != rsaBitsOrDefault(req.StateValue)
+	}
+}
+
+func rsaBitsOrDefault(v types.Int64) int64 {
+	if v.IsNull() {
+		return defaultRSABits
+	}
+	return v.ValueInt64()
+}
+
+// ecdsaCurvePlanModifier does for ecdsa_curve what rsaBitsPlanModifier does
+// for rsa_bits; an unset curve is P256.
+type ecdsaCurvePlanModifier struct{}
+
+func (m ecdsaCurvePlanModifier) Description(ctx context.Context) string {
+	return "Requires replacement when the ECDSA curve changes; an unset curve is P256."
+}
+
+func (m ecdsaCurvePlanModifier) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m ecdsaCurvePlanModifier) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	if req.State.Raw.IsNull() || !keepsKeyAlgorithm(ctx, req.State, req.Plan) {
+		if req.ConfigValue.IsNull() {
+			resp.PlanValue = types.StringNull()
+		}
+		return
+	}
+	switch {
+	case req.ConfigValue.IsNull():
+		resp.PlanValue = req.StateValue
+	case req.ConfigValue.IsUnknown():
+		resp.RequiresReplace = true
+	default:
+		resp.RequiresReplace = req.ConfigValue.ValueString() != ecdsaCurveOrDefault(req.StateValue)
+	}
+}
+
+func ecdsaCurveOrDefault(v types.String) string {
+	if v.IsNull() {
+		return defaultECDSACurve
+	}
+	return v.ValueString()
+}
+
+// keepsKeyAlgorithm reports whether the plan keeps the stored algorithm. A
+// new algorithm replaces the key anyway and the old size or curve must not
+// carry over.
+func keepsKeyAlgorithm(ctx context.Context, state tfsdk.State, plan tfsdk.Plan) bool {
+	var before, after types.String
+	state.GetAttribute(ctx, path.Root("algorithm"), &before)
+	plan.GetAttribute(ctx, path.Root("algorithm"), &after)
+	return !after.IsUnknown() && after.Equal(before)
+}
+
+func (r *privateKeyResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	resp.State.RemoveResource(ctx)
+}
@@ -0,0 +1,202 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	pfresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestValidatePrivateKeyConfig(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		data    privateKeyResourceModel
+		wantErr bool
+	}{
+		{name: "rsa", data: privateKeyResourceModel{Algorithm: types.StringValue("RSA"), RSABits: types.Int64Value(4096)}},
+		{name: "ecdsa", data: privateKeyResourceModel{Algorithm: types.StringValue("ECDSA"), ECDSACurve: types.StringValue("P384")}},
+		{name: "ed25519", data: privateKeyResourceModel{Algorithm: types.StringValue("ED25519")}},
+		{name: "unknown algorithm", data: privateKeyResourceModel{Algorithm: types.StringUnknown()}},
+		{name: "invalid algorithm", data: privateKeyResourceModel{Algorithm: types.StringValue("DSA")}, wantErr: true},
+		{name: "invalid rsa bits", data: privateKeyResourceModel{Algorithm: types.StringValue("RSA"), RSABits: types.Int64Value(1024)}, wantErr: true},
+		{name: "rsa bits for ecdsa", data: privateKeyResourceModel{Algorithm: types.StringValue("ECDSA"), RSABits: types.Int64Value(2048)}, wantErr: true},
+		{name: "invalid curve", data: privateKeyResourceModel{Algorithm: types.StringValue("ECDSA"), ECDSACurve: types.StringValue("P521")}, wantErr: true},
+		{name: "curve for rsa", data: privateKeyResourceModel{Algorithm: types.StringValue("RSA"), ECDSACurve: types.StringValue("P256")}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if diags := validatePrivateKeyConfig(tc.data); diags.HasError() != tc.wantErr {
+				t.Fatalf("expected error=%t got %v", tc.wantErr, diags)
+			}
+		})
+	}
+}
+
+func TestPrivateKeyResourceCreate(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	r := NewPrivateKeyResource()
+	var schemaResp pfresource.SchemaResponse
+	r.Schema(ctx, pfresource.SchemaRequest{}, &schemaResp)
+
+	plan := tfsdk.Plan{Schema: schemaResp.Schema}
+	if diags := plan.Set(ctx, &privateKeyResourceModel{
+		Algorithm:            types.StringValue("ECDSA"),
+		RSABits:              types.Int64Null(),
+		ECDSACurve:           types.StringNull(),
+		PrivateKeyPEM:        types.StringUnknown(),
+		PublicKeyPEM:         types.StringUnknown(),
+		PublicKeyFingerprint: types.StringUnknown(),
+	}); diags.HasError() {
+		t.Fatalf("set plan: %v", diags)
+	}
+	resp := pfresource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	r.Create(ctx, pfresource.CreateRequest{Plan: plan}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("create: %v", resp.Diagnostics)
+	}
+
+	var got privateKeyResourceModel
+	resp.State.Get(ctx, &got)
+	key, err := parsePrivateKeyPEM(got.PrivateKeyPEM.ValueString())
+	if err != nil {
+		t.Fatalf("parse generated key: %v", err)
+	}
+	pubPEM, fingerprint, err := publicKeyPEM(key)
+	if err != nil {
+		t.Fatalf("public key: %v", err)
+	}
+	if got.PublicKeyPEM.ValueString() != pubPEM || got.PublicKeyFingerprint.ValueString() != fingerprint {
+		t.Fatalf("public key attributes do not match the private key")
+	}
+}
+
+func TestPrivateKeyResourceImport(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	r := &privateKeyResource{}
+	var schemaResp pfresource.SchemaResponse
+	r.Schema(ctx, pfresource.SchemaRequest{}, &schemaResp)
+
+	tests := []struct {
+		algorithm string
+		rsaBits   int
+		curve     string
+		want      privateKeyResourceModel
+	}{
+		{algorithm: "RSA", rsaBits: 2048, want: privateKeyResourceModel{Algorithm: types.StringValue("RSA"), RSABits: types.Int64Value(2048), ECDSACurve: types.StringNull()}},
+		{algorithm: "ECDSA", curve: "P384", want: privateKeyResourceModel{Algorithm: types.StringValue("ECDSA"), RSABits: types.Int64Null(), ECDSACurve: types.StringValue("P384")}},
+		{algorithm: "ED25519", want: privateKeyResourceModel{Algorithm: types.StringValue("ED25519"), RSABits: types.Int64Null(), ECDSACurve: types.StringNull()}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.algorithm, func(t *testing.T) {
+			key, err := generatePrivateKey(tc.algorithm, tc.rsaBits, tc.curve)
+			if err != nil {
+				t.Fatalf("generate key: %v", err)
+			}
+			keyPEM, err := encodePrivateKeyPEM(key)
+			if err != nil {
+				t.Fatalf("encode key: %v", err)
+			}
+			keyFile := filepath.Join(t.TempDir(), "key.pem")
+			if err := os.WriteFile(keyFile, []byte(keyPEM), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			resp := pfresource.ImportStateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+			r.ImportState(ctx, pfresource.ImportStateRequest{ID: keyFile}, &resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("import: %v", resp.Diagnostics)
+			}
+			var got privateKeyResourceModel
+			resp.State.Get(ctx, &got)
+			pubPEM, fingerprint, _ := publicKeyPEM(key)
+			if got.Algorithm != tc.want.Algorithm || got.RSABits != tc.want.RSABits || got.ECDSACurve != tc.want.ECDSACurve ||
+				got.PrivateKeyPEM.ValueString() != keyPEM || got.PublicKeyPEM.ValueString() != pubPEM || got.PublicKeyFingerprint.ValueString() != fingerprint {
+				t.Fatalf("unexpected state: %#v", got)
+			}
+		})
+	}
+
+	resp := pfresource.ImportStateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	r.ImportState(ctx, pfresource.ImportStateRequest{ID: "/path/to/missing.pem"}, &resp)
+	if !resp.Diagnostics.HasError() {
+		t.Fatal("expected an error for an ID that is not a key file")
+	}
+}
+
+func TestPrivateKeyResourcePlanAfterImport(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	r := &privateKeyResource{}
+	var schemaResp pfresource.SchemaResponse
+	r.Schema(ctx, pfresource.SchemaRequest{}, &schemaResp)
+
+	tests := []struct {
+		name        string
+		algorithm   string
+		rsaBits     types.Int64
+		curve       types.String
+		wantReplace bool
+	}{
+		{name: "RSA default omitted", algorithm: "RSA", rsaBits: types.Int64Null(), curve: types.StringNull()},
+		{name: "RSA default explicit", algorithm: "RSA", rsaBits: types.Int64Value(2048), curve: types.StringNull()},
+		{name: "RSA larger", algorithm: "RSA", rsaBits: types.Int64Value(4096), curve: types.StringNull(), wantReplace: true},
+		{name: "ECDSA default omitted", algorithm: "ECDSA", rsaBits: types.Int64Null(), curve: types.StringNull()},
+		{name: "ECDSA default explicit", algorithm: "ECDSA", rsaBits: types.Int64Null(), curve: types.StringValue("P256")},
+		{name: "ECDSA other curve", algorithm: "ECDSA", rsaBits: types.Int64Null(), curve: types.StringValue("P384"), wantReplace: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			key, err := generatePrivateKey(tc.algorithm, 0, "")
+			if err != nil {
+				t.Fatalf("generate key: %v", err)
+			}
+			imported, err := privateKeyModelFromKey(key)
+			if err != nil {
+				t.Fatalf("import: %v", err)
+			}
+			state := tfsdk.State{Schema: schemaResp.Schema}
+			state.Set(ctx, &imported)
+			configured := privateKeyResourceModel{
+				Algorithm: types.StringValue(tc.algorithm), RSABits: tc.rsaBits, ECDSACurve: tc.curve,
+				PrivateKeyPEM: types.StringNull(), PublicKeyPEM: types.StringNull(), PublicKeyFingerprint: types.StringNull(),
+			}
+			plan := tfsdk.Plan{Schema: schemaResp.Schema}
+			plan.Set(ctx, &configured)
+			config := tfsdk.Config{Schema: schemaResp.Schema, Raw: plan.Raw}
+
+			bitsReq := planmodifier.Int64Request{Path: path.Root("rsa_bits"), Config: config, ConfigValue: tc.rsaBits,
+				State: state, StateValue: imported.RSABits, Plan: plan, PlanValue: tc.rsaBits}
+			bitsResp := &planmodifier.Int64Response{PlanValue: bitsReq.PlanValue}
+			for _, m := range schemaResp.Schema.Attributes["rsa_bits"].(schema.Int64Attribute).PlanModifiers {
+				m.PlanModifyInt64(ctx, bitsReq, bitsResp)
+			}
+			curveReq := planmodifier.StringRequest{Path: path.Root("ecdsa_curve"), Config: config, ConfigValue: tc.curve,
+				State: state, StateValue: imported.ECDSACurve, Plan: plan, PlanValue: tc.curve}
+			curveResp := &planmodifier.StringResponse{PlanValue: curveReq.PlanValue}
+			for _, m := range schemaResp.Schema.Attributes["ecdsa_curve"].(schema.StringAttribute).PlanModifiers {
+				m.PlanModifyString(ctx, curveReq, curveResp)
+			}
+
+			if replace := bitsResp.RequiresReplace || curveResp.RequiresReplace; replace != tc.wantReplace {
+				t.Fatalf("expected replace=%t got %t", tc.wantReplace, replace)
+			}
+			if !tc.wantReplace && (!bitsResp.PlanValue.Equal(bitsReq.StateValue) || !curveResp.PlanValue.Equal(curveReq.StateValue)) {
+				t.Fatalf("expected an empty plan, got rsa_bits=%s ecdsa_curve=%s", bitsResp.PlanValue, curveResp.PlanValue)
+			}
+		})
+	}
+}
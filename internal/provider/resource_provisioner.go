@@ -2,22 +2,32 @@ package provider
 
 import (
 	"context"
+	"reflect"
 
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 
 	"github.com/z0link/terraform-provider-stepca/internal/client"
 )
 
-var _ resource.Resource = &provisionerResource{}
+var (
+	_ resource.Resource                   = &provisionerResource{}
+	_ resource.ResourceWithImportState    = &provisionerResource{}
+	_ resource.ResourceWithValidateConfig = &provisionerResource{}
+)
 
 func NewProvisionerResource() resource.Resource {
 	return &provisionerResource{}
 }
 
 type provisionerClient interface {
+	templateGetter
 	CreateProvisioner(ctx context.Context, p client.Provisioner) error
 	ReplaceProvisioner(ctx context.Context, name string, p client.Provisioner) error
 	DeleteProvisioner(ctx context.Context, name string) error
@@ -29,12 +39,33 @@ type provisionerResource struct {
 }
 
 type provisionerResourceModel struct {
+	ID                  types.String `tfsdk:"id"`
 	Name                types.String `tfsdk:"name"`
 	Type                types.String `tfsdk:"type"`
 	Admin               types.Bool   `tfsdk:"admin"`
 	X509Template        types.String `tfsdk:"x509_template"`
 	SSHTemplate         types.String `tfsdk:"ssh_template"`
 	AttestationTemplate types.String `tfsdk:"attestation_template"`
+
+	X509TemplateData     types.String `tfsdk:"x509_template_data"`
+	SSHTemplateData      types.String `tfsdk:"ssh_template_data"`
+	X509TemplateChecksum types.String `tfsdk:"x509_template_checksum"`
+	SSHTemplateChecksum  types.String `tfsdk:"ssh_template_checksum"`
+
+	JWK    *provisionerJWKModel    `tfsdk:"jwk"`
+	OIDC   *provisionerOIDCModel   `tfsdk:"oidc"`
+	ACME   *provisionerACMEModel   `tfsdk:"acme"`
+	AWS    *provisionerAWSModel    `tfsdk:"aws"`
+	GCP    *provisionerGCPModel    `tfsdk:"gcp"`
+	Azure  *provisionerAzureModel  `tfsdk:"azure"`
+	X5C    *provisionerX5CModel    `tfsdk:"x5c"`
+	K8sSA  *provisionerK8sSAModel  `tfsdk:"k8ssa"`
+	SSHPOP *provisionerSSHPOPModel `tfsdk:"sshpop"`
+	SCEP   *provisionerSCEPModel   `tfsdk:"scep"`
+	Nebula *provisionerNebulaModel `tfsdk:"nebula"`
+
+	Claims *provisionerClaimsModel `tfsdk:"claims"`
+	Policy *provisionerPolicyModel `tfsdk:"policy"`
 }
 
 func (r *provisionerResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -42,16 +73,90 @@ func (r *provisionerResource) Metadata(ctx context.Context, req resource.Metadat
 }
 
 func (r *provisionerResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	blocks := provisionerDetailBlocks()
+	blocks["claims"] = provisionerClaimsBlock()
+	blocks["policy"] = provisionerPolicyBlock()
 	resp.Schema = schema.Schema{
 		Attributes: map[string]schema.Attribute{
-			"name":                 schema.StringAttribute{Required: true},
-			"type":                 schema.StringAttribute{Required: true},
+			"id": schema.StringAttribute{
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"name": schema.StringAttribute{
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"type": schema.StringAttribute{
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
 			"admin":                schema.BoolAttribute{Optional: true},
 			"x509_template":        schema.StringAttribute{Optional: true},
 			"ssh_template":         schema.StringAttribute{Optional: true},
 			"attestation_template": schema.StringAttribute{Optional: true},
+			"x509_template_data": schema.StringAttribute{
+				Optional:    true,
+				Description: "JSON object whose keys step-ca adds to the data of the X.509 template.",
+			},
+			"ssh_template_data": schema.StringAttribute{
+				Optional:    true,
+				Description: "JSON object whose keys step-ca adds to the data of the SSH template.",
+			},
+			"x509_template_checksum": schema.StringAttribute{
+				Optional:    true,
+				Description: "Checksum of the template named by x509_template, usually stepca_template.<name>.checksum. A change pushes the provisioner again.",
+			},
+			"ssh_template_checksum": schema.StringAttribute{
+				Optional:    true,
+				Description: "Checksum of the template named by ssh_template, usually stepca_template.<name>.checksum. A change pushes the provisioner again.",
+			},
 		},
+		Blocks: blocks,
+	}
+}
+
+func (r *provisionerResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data provisionerResourceModel
+	diags := req.Config.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(validateProvisionerDetails(data)...)
+	resp.Diagnostics.Append(validateProvisionerTemplates(data)...)
+	if data.Claims != nil {
+		resp.Diagnostics.Append(validateProvisionerClaims(*data.Claims)...)
+	}
+	if data.Policy != nil {
+		resp.Diagnostics.Append(validatePolicy(path.Root("policy"), data.Policy.X509, data.Policy.SSH)...)
+	}
+}
+
+// prepareDetails completes the detail blocks of plan from the configuration,
+// which carries the write-only values.
+func prepareDetails(ctx context.Context, config tfsdk.Config, plan *provisionerResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if plan.JWK != nil {
+		var password types.String
+		diags.Append(config.GetAttribute(ctx, path.Root("jwk").AtName("password"), &password)...)
+		if diags.HasError() {
+			return diags
+		}
+		diags.Append(prepareJWK(plan.JWK, password)...)
+	}
+	if plan.SCEP != nil {
+		var challenge, key, password types.String
+		diags.Append(config.GetAttribute(ctx, path.Root("scep").AtName("challenge"), &challenge)...)
+		diags.Append(config.GetAttribute(ctx, path.Root("scep").AtName("decrypter_key"), &key)...)
+		diags.Append(config.GetAttribute(ctx, path.Root("scep").AtName("decrypter_key_password"), &password)...)
+		if diags.HasError() {
+			return diags
+		}
+		plan.SCEP.challenge = challenge.ValueString()
+		plan.SCEP.decrypterKey = key.ValueString()
+		plan.SCEP.decrypterKeyPassword = password.ValueString()
 	}
+	return diags
 }
 
 func (r *provisionerResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
@@ -74,11 +179,25 @@ func (r *provisionerResource) Create(ctx context.Context, req resource.CreateReq
 		resp.Diagnostics.AddError("provider not configured", "missing client")
 		return
 	}
+	resp.Diagnostics.Append(prepareDetails(ctx, req.Config, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 	p := provisionerModelToClient(data)
 	if err := r.client.CreateProvisioner(ctx, p); err != nil {
-		resp.Diagnostics.AddError("create failed", err.Error())
+		resp.Diagnostics.Append(clientErrorDiagnostic("create failed", "provisioner", err))
+		return
+	}
+	created, err := r.client.GetProvisioner(ctx, p.Name)
+	if err != nil {
+		resp.Diagnostics.Append(clientErrorDiagnostic("read failed", "provisioner", err))
 		return
 	}
+	if created == nil {
+		resp.Diagnostics.AddError("read failed", "provisioner missing after create")
+		return
+	}
+	data.ID = stringValueOrNull(created.ID)
 	diags = resp.State.Set(ctx, &data)
 	resp.Diagnostics.Append(diags...)
 }
@@ -96,22 +215,38 @@ func (r *provisionerResource) Read(ctx context.Context, req resource.ReadRequest
 	}
 	p, err := r.client.GetProvisioner(ctx, data.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("read failed", err.Error())
+		resp.Diagnostics.Append(clientErrorDiagnostic("read failed", "provisioner", err))
 		return
 	}
 	if p == nil {
 		resp.State.RemoveResource(ctx)
 		return
 	}
+	data.ID = stringValueOrNull(p.ID)
 	data.Type = types.StringValue(p.Type)
 	data.Admin = types.BoolValue(p.Admin)
 	data.X509Template = stringValueOrNull(p.X509Template)
 	data.SSHTemplate = stringValueOrNull(p.SSHTemplate)
 	data.AttestationTemplate = stringValueOrNull(p.AttestationTemplate)
+	data.X509TemplateData = templateDataValue(data.X509TemplateData, p.X509TemplateData)
+	data.SSHTemplateData = templateDataValue(data.SSHTemplateData, p.SSHTemplateData)
+	resp.Diagnostics.Append(refreshTemplateChecksums(ctx, r.client, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	data.setDetails(p.Details)
+	data.setClaims(p.Claims)
+	data.setPolicy(p.Policy)
 	diags = resp.State.Set(ctx, &data)
 	resp.Diagnostics.Append(diags...)
 }
 
+// ImportState adopts an existing provisioner by name, for example one created
+// by `step ca init`. Read fills in the remaining attributes.
+func (r *provisionerResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("name"), req, resp)
+}
+
 func (r *provisionerResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
 	var plan provisionerResourceModel
 	var state provisionerResourceModel
@@ -125,6 +260,10 @@ func (r *provisionerResource) Update(ctx context.Context, req resource.UpdateReq
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	resp.Diagnostics.Append(prepareDetails(ctx, req.Config, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 	updated, updateDiags := r.updateProvisioner(ctx, &state, &plan)
 	resp.Diagnostics.Append(updateDiags...)
 	if resp.Diagnostics.HasError() {
@@ -153,17 +292,27 @@ func (r *provisionerResource) updateProvisioner(ctx context.Context, state, plan
 	shouldReplace := planAdmin != stateAdmin ||
 		!stringAttrEqual(plan.X509Template, state.X509Template) ||
 		!stringAttrEqual(plan.SSHTemplate, state.SSHTemplate) ||
-		!stringAttrEqual(plan.AttestationTemplate, state.AttestationTemplate)
+		!stringAttrEqual(plan.AttestationTemplate, state.AttestationTemplate) ||
+		!templateDataEqual(plan.X509TemplateData, state.X509TemplateData) ||
+		!templateDataEqual(plan.SSHTemplateData, state.SSHTemplateData) ||
+		// A changed checksum means a referenced stepca_template was
+		// updated; pushing the provisioner again makes step-ca pick up the
+		// new template body.
+		!stringAttrEqual(plan.X509TemplateChecksum, state.X509TemplateChecksum) ||
+		!stringAttrEqual(plan.SSHTemplateChecksum, state.SSHTemplateChecksum) ||
+		provisionerDetailsChanged(*plan, *state) ||
+		!reflect.DeepEqual(provisionerClaimsToClient(plan.Claims), provisionerClaimsToClient(state.Claims)) ||
+		!reflect.DeepEqual(provisionerPolicyToClient(plan.Policy), provisionerPolicyToClient(state.Policy))
 	if shouldReplace {
 		payload := provisionerModelToClient(*plan)
 		if err := r.client.ReplaceProvisioner(ctx, state.Name.ValueString(), payload); err != nil {
-			diags.AddError("update failed", err.Error())
+			diags.Append(clientErrorDiagnostic("update failed", "provisioner", err))
 			return nil, diags
 		}
 	}
 	updated, err := r.client.GetProvisioner(ctx, plan.Name.ValueString())
 	if err != nil {
-		diags.AddError("read failed", err.Error())
+		diags.Append(clientErrorDiagnostic("read failed", "provisioner", err))
 		return nil, diags
 	}
 	if updated == nil {
@@ -171,12 +320,34 @@ func (r *provisionerResource) updateProvisioner(ctx context.Context, state, plan
 		return nil, diags
 	}
 	result := &provisionerResourceModel{
+		ID:                  stringValueOrNull(updated.ID),
 		Name:                types.StringValue(updated.Name),
 		Type:                types.StringValue(updated.Type),
 		Admin:               types.BoolValue(updated.Admin),
 		X509Template:        stringValueOrNull(updated.X509Template),
 		SSHTemplate:         stringValueOrNull(updated.SSHTemplate),
 		AttestationTemplate: stringValueOrNull(updated.AttestationTemplate),
+
+		X509TemplateData:     templateDataValue(plan.X509TemplateData, updated.X509TemplateData),
+		SSHTemplateData:      templateDataValue(plan.SSHTemplateData, updated.SSHTemplateData),
+		X509TemplateChecksum: plan.X509TemplateChecksum,
+		SSHTemplateChecksum:  plan.SSHTemplateChecksum,
+
+		// The detail blocks were just sent as planned; Read picks up any
+		// later drift.
+		JWK:    plan.JWK,
+		OIDC:   plan.OIDC,
+		ACME:   plan.ACME,
+		AWS:    plan.AWS,
+		GCP:    plan.GCP,
+		Azure:  plan.Azure,
+		X5C:    plan.X5C,
+		K8sSA:  plan.K8sSA,
+		SSHPOP: plan.SSHPOP,
+		SCEP:   plan.SCEP,
+		Nebula: plan.Nebula,
+		Claims: plan.Claims,
+		Policy: plan.Policy,
 	}
 	return result, diags
 }
@@ -186,6 +357,13 @@ func provisionerModelToClient(data provisionerResourceModel) client.Provisioner
 		Name:  data.Name.ValueString(),
 		Type:  data.Type.ValueString(),
 		Admin: boolFromOptional(data.Admin),
+
+		X509TemplateData: templateDataToClient(data.X509TemplateData),
+		SSHTemplateData:  templateDataToClient(data.SSHTemplateData),
+
+		Details: provisionerDetailsToClient(data),
+		Claims:  provisionerClaimsToClient(data.Claims),
+		Policy:  provisionerPolicyToClient(data.Policy),
 	}
 	if v, ok := optionalStringValue(data.X509Template); ok {
 		p.X509Template = v
@@ -245,7 +423,7 @@ func (r *provisionerResource) Delete(ctx context.Context, req resource.DeleteReq
 		return
 	}
 	if err := r.client.DeleteProvisioner(ctx, data.Name.ValueString()); err != nil {
-		resp.Diagnostics.AddError("delete failed", err.Error())
+		resp.Diagnostics.Append(clientErrorDiagnostic("delete failed", "provisioner", err))
 		return
 	}
 }
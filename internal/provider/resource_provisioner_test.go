@@ -7,18 +7,24 @@ import (
 	"github.com/google/go-cmp/cmp"
 	pfresource "github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 
 	"github.com/z0link/terraform-provider-stepca/internal/client"
 )
 
 type fakeProvisionerClient struct {
+	createInput   client.Provisioner
 	replaceCalled bool
 	replaceInput  client.Provisioner
 	getResp       *client.Provisioner
+	templates     map[string]string
 }
 
 func (f *fakeProvisionerClient) CreateProvisioner(ctx context.Context, p client.Provisioner) error {
+	f.createInput = p
 	return nil
 }
 
@@ -34,24 +40,106 @@ func (f *fakeProvisionerClient) GetProvisioner(ctx context.Context, name string)
 	return f.getResp, nil
 }
 
+func (f *fakeProvisionerClient) GetTemplate(ctx context.Context, name string) (*client.Template, error) {
+	body, ok := f.templates[name]
+	if !ok {
+		return nil, nil
+	}
+	return &client.Template{Name: name, Body: body}, nil
+}
+
+// comparePlanModifiers compares plan modifiers by description, since
+// modifiers such as RequiresReplace wrap functions cmp cannot compare.
+var comparePlanModifiers = cmp.Transformer("planModifiers", func(modifiers []planmodifier.String) []string {
+	descriptions := make([]string, 0, len(modifiers))
+	for _, m := range modifiers {
+		descriptions = append(descriptions, m.Description(context.Background()))
+	}
+	return descriptions
+})
+
 func TestProvisionerResourceSchema(t *testing.T) {
 	t.Parallel()
 	resource := NewProvisionerResource()
 	var resp pfresource.SchemaResponse
 	resource.Schema(context.Background(), pfresource.SchemaRequest{}, &resp)
+	blocks := provisionerDetailBlocks()
+	blocks["claims"] = provisionerClaimsBlock()
+	blocks["policy"] = provisionerPolicyBlock()
 	expected := schema.Schema{
 		Attributes: map[string]schema.Attribute{
-			"name":                 schema.StringAttribute{Required: true},
-			"type":                 schema.StringAttribute{Required: true},
+			"id": schema.StringAttribute{
+				Computed:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
+			"name": schema.StringAttribute{
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"type": schema.StringAttribute{
+				Required:      true,
+				PlanModifiers: []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
 			"admin":                schema.BoolAttribute{Optional: true},
 			"x509_template":        schema.StringAttribute{Optional: true},
 			"ssh_template":         schema.StringAttribute{Optional: true},
 			"attestation_template": schema.StringAttribute{Optional: true},
+			"x509_template_data": schema.StringAttribute{
+				Optional:    true,
+				Description: "JSON object whose keys step-ca adds to the data of the X.509 template.",
+			},
+			"ssh_template_data": schema.StringAttribute{
+				Optional:    true,
+				Description: "JSON object whose keys step-ca adds to the data of the SSH template.",
+			},
+			"x509_template_checksum": schema.StringAttribute{
+				Optional:    true,
+				Description: "Checksum of the template named by x509_template, usually stepca_template.<name>.checksum. A change pushes the provisioner again.",
+			},
+			"ssh_template_checksum": schema.StringAttribute{
+				Optional:    true,
+				Description: "Checksum of the template named by ssh_template, usually stepca_template.<name>.checksum. A change pushes the provisioner again.",
+			},
 		},
+		Blocks: blocks,
 	}
-	if diff := cmp.Diff(expected, resp.Schema); diff != "" {
+	if diff := cmp.Diff(expected, resp.Schema, comparePlanModifiers); diff != "" {
 		t.Fatalf("unexpected schema: (-want +got)\n%s", diff)
 	}
+	if diags := resp.Schema.ValidateImplementation(context.Background()); diags.HasError() {
+		t.Fatalf("invalid schema: %v", diags)
+	}
+}
+
+func TestProvisionerResourceCreateSetsID(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fake := &fakeProvisionerClient{getResp: &client.Provisioner{ID: "0a1b2c", Name: "api", Type: "JWK"}}
+	r := &provisionerResource{client: fake}
+	var schemaResp pfresource.SchemaResponse
+	r.Schema(ctx, pfresource.SchemaRequest{}, &schemaResp)
+
+	plan := tfsdk.Plan{Schema: schemaResp.Schema}
+	plan.Set(ctx, &provisionerResourceModel{
+		ID:                  types.StringUnknown(),
+		Name:                types.StringValue("api"),
+		Type:                types.StringValue("JWK"),
+		Admin:               types.BoolNull(),
+		X509Template:        types.StringNull(),
+		SSHTemplate:         types.StringNull(),
+		AttestationTemplate: types.StringNull(),
+	})
+	resp := pfresource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema}}
+	r.Create(ctx, pfresource.CreateRequest{Plan: plan}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("create: %v", resp.Diagnostics)
+	}
+	var got provisionerResourceModel
+	resp.State.Get(ctx, &got)
+	if got.ID.ValueString() != "0a1b2c" {
+		t.Fatalf("expected the step-ca ID in state, got %v", got.ID)
+	}
 }
 
 func TestProvisionerResourceUpdateAdminToggle(t *testing.T) {
@@ -2,8 +2,11 @@ package provider
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/types"
@@ -11,7 +14,11 @@ import (
 	"github.com/z0link/terraform-provider-stepca/internal/client"
 )
 
-var _ resource.Resource = &templateResource{}
+var (
+	_ resource.Resource                = &templateResource{}
+	_ resource.ResourceWithImportState = &templateResource{}
+	_ resource.ResourceWithModifyPlan  = &templateResource{}
+)
 
 // templateGetter captures the helper interface for retrieving templates by name.
 type templateGetter interface {
@@ -34,6 +41,7 @@ type templateResourceModel struct {
 	Name     types.String `tfsdk:"name"`
 	Body     types.String `tfsdk:"body"`
 	Metadata types.Map    `tfsdk:"metadata"`
+	Checksum types.String `tfsdk:"checksum"`
 }
 
 func (r *templateResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -49,10 +57,28 @@ func (r *templateResource) Schema(ctx context.Context, req resource.SchemaReques
 				Optional:    true,
 				ElementType: types.StringType,
 			},
+			"checksum": schema.StringAttribute{
+				Computed:    true,
+				Description: "SHA-256 of the body. Set it as x509_template_checksum or ssh_template_checksum of a stepca_provisioner to push the provisioner again whenever the body changes.",
+			},
 		},
 	}
 }
 
+// ModifyPlan computes the checksum of a known body, so provisioners that
+// track it only plan an update when the body actually changes.
+func (r *templateResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	var body types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("body"), &body)...)
+	if resp.Diagnostics.HasError() || body.IsUnknown() {
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("checksum"), templateChecksum(body.ValueString()))...)
+}
+
 func (r *templateResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	if req.ProviderData == nil {
 		return
@@ -82,10 +108,11 @@ func (r *templateResource) Create(ctx context.Context, req resource.CreateReques
 	}
 
 	if err := r.client.CreateTemplate(ctx, tmpl); err != nil {
-		resp.Diagnostics.AddError("create failed", err.Error())
+		resp.Diagnostics.Append(clientErrorDiagnostic("create failed", "template", err))
 		return
 	}
 
+	data.Checksum = types.StringValue(templateChecksum(data.Body.ValueString()))
 	if diags := setMetadataState(ctx, &data, tmpl.Metadata); diags.HasError() {
 		resp.Diagnostics.Append(diags...)
 		return
@@ -109,7 +136,7 @@ func (r *templateResource) Read(ctx context.Context, req resource.ReadRequest, r
 
 	tmpl, err := r.client.GetTemplate(ctx, data.Name.ValueString())
 	if err != nil {
-		resp.Diagnostics.AddError("read failed", err.Error())
+		resp.Diagnostics.Append(clientErrorDiagnostic("read failed", "template", err))
 		return
 	}
 	if tmpl == nil {
@@ -118,6 +145,7 @@ func (r *templateResource) Read(ctx context.Context, req resource.ReadRequest, r
 	}
 
 	data.Body = types.StringValue(tmpl.Body)
+	data.Checksum = types.StringValue(templateChecksum(data.Body.ValueString()))
 	if diags := setMetadataState(ctx, &data, tmpl.Metadata); diags.HasError() {
 		resp.Diagnostics.Append(diags...)
 		return
@@ -126,6 +154,12 @@ func (r *templateResource) Read(ctx context.Context, req resource.ReadRequest, r
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
+// ImportState adopts an existing template by name. Read fills in the body
+// and metadata.
+func (r *templateResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	resource.ImportStatePassthroughID(ctx, path.Root("name"), req, resp)
+}
+
 func (r *templateResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
 	if r.client == nil {
 		resp.Diagnostics.AddError("provider not configured", "missing client")
@@ -146,10 +180,11 @@ func (r *templateResource) Update(ctx context.Context, req resource.UpdateReques
 	}
 
 	if err := r.client.UpdateTemplate(ctx, tmpl); err != nil {
-		resp.Diagnostics.AddError("update failed", err.Error())
+		resp.Diagnostics.Append(clientErrorDiagnostic("update failed", "template", err))
 		return
 	}
 
+	data.Checksum = types.StringValue(templateChecksum(data.Body.ValueString()))
 	if diags := setMetadataState(ctx, &data, tmpl.Metadata); diags.HasError() {
 		resp.Diagnostics.Append(diags...)
 		return
@@ -172,7 +207,7 @@ func (r *templateResource) Delete(ctx context.Context, req resource.DeleteReques
 	}
 
 	if err := r.client.DeleteTemplate(ctx, data.Name.ValueString()); err != nil {
-		resp.Diagnostics.AddError("delete failed", err.Error())
+		resp.Diagnostics.Append(clientErrorDiagnostic("delete failed", "template", err))
 	}
 }
 
@@ -205,3 +240,9 @@ func setMetadataState(ctx context.Context, data *templateResourceModel, metadata
 	data.Metadata = val
 	return nil
 }
+
+// templateChecksum returns the hex-encoded SHA-256 of a template body.
+func templateChecksum(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
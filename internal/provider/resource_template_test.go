@@ -7,6 +7,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	pfresource "github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
@@ -22,6 +23,10 @@ func TestTemplateResourceSchema(t *testing.T) {
 			"name":     schema.StringAttribute{Required: true},
 			"body":     schema.StringAttribute{Required: true},
 			"metadata": schema.MapAttribute{Optional: true, ElementType: types.StringType},
+			"checksum": schema.StringAttribute{
+				Computed:    true,
+				Description: "SHA-256 of the body. Set it as x509_template_checksum or ssh_template_checksum of a stepca_provisioner to push the provisioner again whenever the body changes.",
+			},
 		},
 	}
 
@@ -29,3 +34,41 @@ func TestTemplateResourceSchema(t *testing.T) {
 		t.Fatalf("unexpected schema: (-want +got)\n%s", diff)
 	}
 }
+
+func TestTemplateResourceModifyPlan(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	var schemaResp pfresource.SchemaResponse
+	NewTemplateResource().Schema(ctx, pfresource.SchemaRequest{}, &schemaResp)
+
+	tests := []struct {
+		name string
+		body types.String
+		want types.String
+	}{
+		{name: "known body", body: types.StringValue("{}"), want: types.StringValue("44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a")},
+		{name: "unknown body", body: types.StringUnknown(), want: types.StringUnknown()},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			plan := tfsdk.Plan{Schema: schemaResp.Schema}
+			plan.Set(ctx, &templateResourceModel{
+				Name:     types.StringValue("leaf"),
+				Body:     tc.body,
+				Metadata: types.MapNull(types.StringType),
+				Checksum: types.StringUnknown(),
+			})
+			resp := pfresource.ModifyPlanResponse{Plan: plan}
+			(&templateResource{}).ModifyPlan(ctx, pfresource.ModifyPlanRequest{Plan: plan}, &resp)
+			if resp.Diagnostics.HasError() {
+				t.Fatalf("modify plan: %v", resp.Diagnostics)
+			}
+			var got templateResourceModel
+			resp.Plan.Get(ctx, &got)
+			if !got.Checksum.Equal(tc.want) {
+				t.Fatalf("expected checksum %s, got %s", tc.want, got.Checksum)
+			}
+		})
+	}
+}
@@ -14,7 +14,7 @@ func GetTemplate(ctx context.Context, getter templateGetter, name string) (strin
 
 	tmpl, err := getter.GetTemplate(ctx, name)
 	if err != nil {
-		diags = append(diags, diag.NewErrorDiagnostic("get template failed", err.Error()))
+		diags = append(diags, clientErrorDiagnostic("get template failed", "template", err))
 		return "", types.MapNull(types.StringType), diags
 	}
 	if tmpl == nil {